@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+	"github.com/lukasmalkmus/hkcode/hk/text"
+	"github.com/lukasmalkmus/hkcode/internal/manifest"
+	"github.com/lukasmalkmus/hkcode/internal/registry"
+)
+
+// batchRow is a row of the batch input. Fields which are not given are nil.
+type batchRow struct {
+	Serial       *string      `json:"serial"`
+	SerialNumber *string      `json:"serial_number"`
+	Code         *hk.Code     `json:"code"`
+	ID           *string      `json:"id"`
+	Flags        *hk.Flag     `json:"flags"`
+	Category     *hk.Category `json:"category"`
+	DeviceID     *hk.DeviceID `json:"device_id"`
+}
+
+// batchItem is a code to create in a batch.
+type batchItem struct {
+	line int
+	info hk.SetupInfo
+	name string
+	data []byte
+	err  error
+}
+
+// batchName is the data the file name template is executed with. The serial
+// number defaults to the line number.
+type batchName struct {
+	Serial   string
+	Code     string
+	ID       string
+	Flags    string
+	Category string
+	Line     int
+}
+
+func batch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		createFlags     createFlags
+		registryFlags   registryFlags
+		manifestFlags   manifestFlags
+		nameFlag        string
+		inputFormatFlag string
+		jobsFlag        int
+	)
+	createFlags.register(fs)
+	registryFlags.register(fs, true)
+	manifestFlags.register(fs)
+	fs.StringVar(&nameFlag, "name", "{{.Serial}}-{{.ID}}.png", "file name `TEMPLATE`")
+	fs.StringVar(&inputFormatFlag, "input-format", "", "input `FORMAT`")
+	fs.IntVar(&jobsFlag, "j", runtime.NumCPU(), "number of `JOBS` to run in parallel")
+	fs.IntVar(&jobsFlag, "jobs", runtime.NumCPU(), "number of `JOBS` to run in parallel")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the input file must be specified after all flags")
+	}
+	if !createFlags.text && !createFlags.qr {
+		errorWithHint("missing mode",
+			"did you forget to specify one of -t/--text or -q/--qr?")
+	}
+	if createFlags.term {
+		errorf("--term can't be used with batch")
+	}
+	if jobsFlag < 1 {
+		errorf("invalid number of jobs %d: must be positive", jobsFlag)
+	}
+	manifestFlags.load()
+
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameFlag)
+	if err != nil {
+		errorWithHint(fmt.Sprintf("invalid file name template: %v", err),
+			`--name is a Go template like "{{.Serial}}-{{.ID}}.png"`)
+	}
+
+	// The output is a directory and the format follows from the file names.
+	dir := createFlags.out
+	if dir == "" {
+		dir = "."
+	}
+	createFlags.out = dir
+	if createFlags.format == "" && strings.EqualFold(filepath.Ext(nameFlag), ".svg") {
+		createFlags.format = "svg"
+	}
+	createFlags.validate()
+
+	in, inName := io.Reader(os.Stdin), "stdin"
+	if arg := fs.Arg(0); arg != "" && arg != "-" {
+		f, err := os.Open(arg)
+		if err != nil {
+			errorf("failed to open input file: %v", err)
+		}
+		defer f.Close()
+		in, inName = f, arg
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		errorf("failed to read %s: %v", inName, err)
+	}
+
+	inputFormat := strings.ToLower(inputFormatFlag)
+	if inputFormat == "" {
+		inputFormat = detectInputFormat(inName, data)
+	}
+	var rows []batchRowAt
+	switch inputFormat {
+	case "csv":
+		rows, err = readCSVRows(data)
+	case "jsonl":
+		rows, err = readJSONRows(data)
+	default:
+		errorWithHint(fmt.Sprintf("unknown input format %q", inputFormatFlag),
+			`--input-format must be one of "csv" or "jsonl"`)
+	}
+	if err != nil {
+		errorf("failed to read %s: %v", inName, err)
+	}
+	if len(rows) == 0 {
+		errorWithHint("no rows given",
+			"pass a CSV or JSON Lines file or pipe it via stdin")
+	}
+
+	// Issue the setup codes through the registry, so none is issued twice.
+	// Generated values are generated again if they are registered already.
+	reg := registryFlags.open()
+
+	items := make([]batchItem, len(rows))
+	used := make(map[string]int, len(rows))
+	for i, row := range rows {
+		item := &items[i]
+		item.line = row.line
+		if row.err != nil {
+			item.err = row.err
+			continue
+		}
+		if item.info, item.err = createFlags.batchInfo(row.batchRow); item.err != nil {
+			continue
+		}
+		if reg != nil {
+			genID := row.ID == nil && createFlags.setupID == ""
+			if item.err = reserve(reg, &item.info, createFlags.policy(), row.Code == nil, genID); item.err != nil {
+				continue
+			}
+		}
+
+		var name strings.Builder
+		if item.err = tmpl.Execute(&name, newBatchName(item.info, row.line)); item.err != nil {
+			item.err = fmt.Errorf("file name: %w", item.err)
+			continue
+		}
+		if item.name, item.err = batchPath(dir, name.String()); item.err != nil {
+			continue
+		}
+		if line, ok := used[item.name]; ok {
+			item.err = fmt.Errorf("file name %q is already used by line %d", item.name, line)
+			continue
+		}
+		used[item.name] = row.line
+	}
+
+	// Render the codes with a bounded number of workers.
+	qrOpts, textOpts := createFlags.options()
+	work := make(chan *batchItem)
+	var wg sync.WaitGroup
+	for i := 0; i < jobsFlag; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				item.data, item.err = createFlags.writeFile(item.name, item.info, qrOpts, textOpts)
+			}
+		}()
+	}
+	for i := range items {
+		if items[i].err == nil {
+			work <- &items[i]
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	// The manifest and registry record the created codes, even if others
+	// failed.
+	var (
+		entries []manifest.Entry
+		records []registry.Record
+		failed  int
+	)
+	for _, item := range items {
+		if item.err == nil {
+			var rel string
+			if rel, item.err = filepath.Rel(dir, item.name); item.err == nil {
+				var entry manifest.Entry
+				if entry, item.err = manifest.NewEntry(item.info, rel, item.data); item.err == nil {
+					entries = append(entries, entry)
+					records = append(records, registryFlags.record(item.info))
+				}
+			}
+		}
+		if item.err != nil {
+			log.Printf("hkcode: error: line %d: %v", item.line, item.err)
+			failed++
+		}
+	}
+	if reg != nil {
+		if err := reg.Add(records...); err != nil {
+			errorf("failed to register setup codes: %v", err)
+		} else if err := reg.Close(); err != nil {
+			errorf("failed to close registry: %v", err)
+		}
+	}
+	if err := writeManifests(dir, entries, &manifestFlags); err != nil {
+		errorf("failed to write manifest: %v", err)
+	}
+	if failed > 0 {
+		errorf("failed to create %d of %d codes", failed, len(items))
+	}
+	fmt.Fprintf(os.Stdout, "Created %d codes in %s\n", len(items), dir)
+}
+
+// batchInfo returns the setup information of the row. The flags and category
+// given as options are used if the row has none. Missing setup codes and ids
+// are generated, like device ids of QR codes. Generated setup codes comply
+// with the policy.
+func (f *createFlags) batchInfo(row batchRow) (hk.SetupInfo, error) {
+	info := hk.SetupInfo{
+		Flags:    f.setupFlags.Flag,
+		Category: f.category.Category,
+	}
+
+	var err error
+	switch {
+	case row.Serial != nil && row.SerialNumber != nil:
+		return hk.SetupInfo{}, fmt.Errorf("serial and serial_number given")
+	case row.Serial != nil:
+		info.SerialNumber = *row.Serial
+	case row.SerialNumber != nil:
+		info.SerialNumber = *row.SerialNumber
+	}
+
+	if row.Code != nil {
+		info.Code = *row.Code
+	} else if info.Code, err = f.policy().GenerateCode(nil); err != nil {
+		return hk.SetupInfo{}, fmt.Errorf("generate setup code: %w", err)
+	}
+
+	switch {
+	case row.ID != nil:
+		if info.ID, err = hk.ParseID(*row.ID); err != nil {
+			return hk.SetupInfo{}, err
+		}
+	case f.setupID != "":
+		if info.ID, err = hk.ParseID(f.setupID); err != nil {
+			return hk.SetupInfo{}, err
+		}
+	default:
+		if info.ID, err = hk.GenerateID(nil); err != nil {
+			return hk.SetupInfo{}, fmt.Errorf("generate setup id: %w", err)
+		}
+	}
+
+	if row.Flags != nil {
+		info.Flags = *row.Flags
+	}
+	if row.Category != nil {
+		info.Category = *row.Category
+	}
+
+	if row.DeviceID != nil {
+		info.DeviceID = row.DeviceID
+	} else if f.qr {
+		deviceID, err := hk.GenerateDeviceID(nil)
+		if err != nil {
+			return hk.SetupInfo{}, fmt.Errorf("generate device id: %w", err)
+		}
+		info.DeviceID = &deviceID
+	}
+	return info, nil
+}
+
+// writeFile creates the code and writes it to the file at path, creating
+// missing directories, and returns the file contents. Nothing is written if the
+// code can't be created.
+func (f *createFlags) writeFile(path string, info hk.SetupInfo, qrOpts []qr.Option, textOpts []text.Option) ([]byte, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := f.write(&buf, info, qrOpts, textOpts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// writeManifests writes the manifest entries as CSV and JSON to dir, encrypted
+// and signed if requested.
+func writeManifests(dir string, entries []manifest.Entry, mf *manifestFlags) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var csvBuf, jsonBuf bytes.Buffer
+	if err := manifest.WriteCSV(&csvBuf, entries); err != nil {
+		return err
+	} else if err := manifest.WriteJSON(&jsonBuf, entries); err != nil {
+		return err
+	}
+
+	for name, data := range map[string][]byte{
+		manifest.CSVName:  csvBuf.Bytes(),
+		manifest.JSONName: jsonBuf.Bytes(),
+	} {
+		if err := mf.writeFile(filepath.Join(dir, name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchPath returns the path of the file with the given name in dir. Names
+// are filled with fields from the input, so they must not leave dir.
+func batchPath(dir, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("file name %q is outside the output directory", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func newBatchName(info hk.SetupInfo, line int) batchName {
+	serial := info.SerialNumber
+	if serial == "" {
+		serial = strconv.Itoa(line)
+	}
+	return batchName{
+		Serial:   serial,
+		Code:     info.Code.String(),
+		ID:       info.ID.String(),
+		Flags:    info.Flags.String(),
+		Category: info.Category.Name(),
+		Line:     line,
+	}
+}
+
+// batchRowAt is a row of the batch input and the line it starts at. Rows which
+// can't be parsed have an error.
+type batchRowAt struct {
+	batchRow
+	line int
+	err  error
+}
+
+// detectInputFormat returns the format of the input by the file extension or,
+// if it has none of the known ones, by its first row.
+func detectInputFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return "csv"
+	case ".jsonl", ".ndjson", ".json":
+		return "jsonl"
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "{") {
+			return "jsonl"
+		}
+		break
+	}
+	return "csv"
+}
+
+// readJSONRows reads one JSON object per line. Empty lines and lines starting
+// with "#" are skipped.
+func readJSONRows(data []byte) ([]batchRowAt, error) {
+	var rows []batchRowAt
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		row := batchRowAt{line: line}
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+		row.err = dec.Decode(&row.batchRow)
+		rows = append(rows, row)
+	}
+	return rows, sc.Err()
+}
+
+// csvColumns are the known CSV columns.
+var csvColumns = map[string]bool{
+	"serial":        true,
+	"serial_number": true,
+	"code":          true,
+	"id":            true,
+	"flags":         true,
+	"category":      true,
+	"device_id":     true,
+}
+
+// readCSVRows reads CSV records with a header naming the columns. Empty cells
+// are treated like missing ones. Lines starting with "#" are skipped.
+func readCSVRows(data []byte) ([]batchRowAt, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if !csvColumns[header[i]] {
+			return nil, fmt.Errorf("unknown column %q, want any of %s", name, quotedKeys(csvColumns))
+		}
+	}
+	r.FieldsPerRecord = len(header)
+
+	var rows []batchRowAt
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, batchRowAt{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		row := batchRowAt{line: line}
+		for i, value := range record {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			if row.err = row.set(header[i], value); row.err != nil {
+				row.err = fmt.Errorf("%s: %w", header[i], row.err)
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// UnmarshalJSON decodes the row like [encoding/json], rejecting unknown fields.
+// Setup codes and categories can be given as JSON numbers as well, like
+// {"code": 12344321, "category": 8}.
+func (row *batchRow) UnmarshalJSON(data []byte) error {
+	type plainRow batchRow
+	var v struct {
+		*plainRow
+		Code     json.RawMessage `json:"code"`
+		Category json.RawMessage `json:"category"`
+	}
+	v.plainRow = (*plainRow)(row)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		column string
+		raw    json.RawMessage
+		digits int
+	}{
+		{"code", v.Code, 8},
+		{"category", v.Category, 0},
+	} {
+		if len(field.raw) == 0 || string(field.raw) == "null" {
+			continue
+		}
+		var value string
+		if field.raw[0] == '"' {
+			if err := json.Unmarshal(field.raw, &value); err != nil {
+				return fmt.Errorf("%s: %w", field.column, err)
+			}
+		} else if n, err := strconv.ParseUint(string(field.raw), 10, 32); err == nil {
+			// Numbers lose the leading zeros of setup codes.
+			value = fmt.Sprintf("%0*d", field.digits, n)
+		} else {
+			return fmt.Errorf("%s: want a string or a non-negative integer, got %s", field.column, field.raw)
+		}
+		if err := row.set(field.column, value); err != nil {
+			return fmt.Errorf("%s: %w", field.column, err)
+		}
+	}
+	return nil
+}
+
+// set sets the field of the column to the value.
+func (row *batchRow) set(column, value string) error {
+	switch column {
+	case "serial":
+		row.Serial = &value
+	case "serial_number":
+		row.SerialNumber = &value
+	case "code":
+		row.Code = new(hk.Code)
+		return row.Code.UnmarshalText([]byte(value))
+	case "id":
+		row.ID = &value
+	case "flags":
+		row.Flags = new(hk.Flag)
+		return row.Flags.UnmarshalText([]byte(value))
+	case "category":
+		row.Category = new(hk.Category)
+		return row.Category.UnmarshalText([]byte(value))
+	case "device_id":
+		row.DeviceID = new(hk.DeviceID)
+		return row.DeviceID.UnmarshalText([]byte(value))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func TestBatchPath(t *testing.T) {
+	path, err := batchPath("codes", "SN1-MHKA.png")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("codes", "SN1-MHKA.png"), path)
+
+	path, err = batchPath("codes", "2024/SN1.png")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("codes", "2024", "SN1.png"), path)
+
+	for _, name := range []string{
+		"../SN1.png",
+		"../../x-MHKA.png",
+		"2024/../../SN1.png",
+		"/etc/x-MHKA.png",
+		"",
+	} {
+		_, err := batchPath("codes", name)
+		assert.ErrorContains(t, err, "outside the output directory", name)
+	}
+}
+
+func TestReadJSONRows(t *testing.T) {
+	rows, err := readJSONRows([]byte(`{"serial":"SN1","code":12344321,"category":8}
+{"serial":"SN2","code":"123-44-322","category":"lightbulb"}
+{"code":1234567}
+{"code":null}
+{"code":true}
+{"category":3.5}
+{"code":"12344321","bogus":1}
+`))
+	require.NoError(t, err)
+	require.Len(t, rows, 7)
+
+	require.NoError(t, rows[0].err)
+	assert.Equal(t, "SN1", *rows[0].Serial)
+	assert.Equal(t, hk.Code(12344321), *rows[0].Code)
+	assert.Equal(t, hk.CategorySwitch, *rows[0].Category)
+
+	require.NoError(t, rows[1].err)
+	assert.Equal(t, hk.Code(12344322), *rows[1].Code)
+	assert.Equal(t, hk.CategoryLightbulb, *rows[1].Category)
+
+	// JSON numbers lose the leading zeros of setup codes.
+	require.NoError(t, rows[2].err)
+	assert.Equal(t, hk.Code(1234567), *rows[2].Code)
+
+	require.NoError(t, rows[3].err)
+	assert.Nil(t, rows[3].Code)
+
+	assert.EqualError(t, rows[4].err, "code: want a string or a non-negative integer, got true")
+	assert.EqualError(t, rows[5].err, "category: want a string or a non-negative integer, got 3.5")
+	assert.EqualError(t, rows[6].err, `json: unknown field "bogus"`)
+}
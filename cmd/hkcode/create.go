@@ -0,0 +1,364 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+	"github.com/lukasmalkmus/hkcode/hk/text"
+	"github.com/lukasmalkmus/hkcode/internal/terminal"
+)
+
+// createFlags are the flags which control the creation of a setup code.
+type createFlags struct {
+	text       bool
+	qr         bool
+	out        string
+	format     string
+	box        bool
+	setupID    string
+	setupFlags setupFlagFlag
+	category   categoryFlag
+	font       fontFlag
+	size       int
+	recovery   recoveryFlag
+	quietZone  int
+	fg, bg     colorFlag
+	canvas     string
+	stroke     int
+	fontSize   float64
+	dpi        float64
+	printSize  float64
+	term       bool
+	policyFlags
+}
+
+func (f *createFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.text, "t", false, "create text code")
+	fs.BoolVar(&f.text, "text", false, "create text code")
+	fs.BoolVar(&f.qr, "q", false, "create qr code")
+	fs.BoolVar(&f.qr, "qr", false, "create qr code")
+	fs.StringVar(&f.out, "o", "", "output to `FILE`")
+	fs.StringVar(&f.out, "output", "", "output to `FILE`")
+	fs.StringVar(&f.format, "format", "", "output `FORMAT`")
+	fs.BoolVar(&f.box, "b", false, "create boxed qr code")
+	fs.BoolVar(&f.box, "box", false, "create boxed qr code")
+	fs.StringVar(&f.setupID, "i", "", "setup id")
+	fs.StringVar(&f.setupID, "id", "", "setup id")
+	fs.Var(&f.setupFlags, "f", "supported pairing methods")
+	fs.Var(&f.setupFlags, "flag", "supported pairing methods")
+	fs.Var(&f.category, "c", "accessory category")
+	fs.Var(&f.category, "category", "accessory category")
+	fs.Var(&f.font, "font", "`FONT` name or file")
+	fs.IntVar(&f.size, "size", 0, "qr code width in `PIXELS`")
+	fs.Var(&f.recovery, "recovery", "qr code error recovery `LEVEL`")
+	fs.IntVar(&f.quietZone, "quiet-zone", -1, "qr code quiet zone in `MODULES`")
+	fs.Var(&f.fg, "fg", "qr code foreground `COLOR`")
+	fs.Var(&f.bg, "bg", "qr code background `COLOR`")
+	fs.StringVar(&f.canvas, "canvas", "", "text code canvas `SIZE`")
+	fs.IntVar(&f.stroke, "stroke", -1, "text code border width in `PIXELS`")
+	fs.Float64Var(&f.fontSize, "font-size", 0, "text code font size in `PIXELS`")
+	fs.Float64Var(&f.dpi, "dpi", 0, "print resolution in `DPI`")
+	fs.Float64Var(&f.printSize, "print-size", 0, "print width in `MM`")
+	fs.BoolVar(&f.term, "term", false, "print qr code to the terminal")
+	f.policyFlags.register(fs)
+}
+
+// validate exits with an error if conflicting flags are given.
+func (f *createFlags) validate() {
+	switch {
+	case f.text:
+		if f.qr {
+			errorf("-q/--qr can't be used with -t/--text")
+		}
+		if f.box {
+			errorf("-b/--box can't be used with -t/--text")
+		}
+		if len(f.setupID) > 0 {
+			errorf("-i/--id can't be used with -t/--text")
+		}
+		if f.setupFlags.Flag != hk.FlagNone {
+			errorf("-f/--flag can't be used with -t/--text")
+		}
+		if f.category.Category > 0 {
+			errorf("-c/--category can't be used with -t/--text")
+		}
+		if f.term {
+			errorf("--term can't be used with -t/--text")
+		}
+		for name, set := range map[string]bool{
+			"size":       f.size != 0,
+			"recovery":   f.recovery.set,
+			"quiet-zone": f.quietZone >= 0,
+			"fg":         f.fg.Color != nil,
+			"bg":         f.bg.Color != nil,
+		} {
+			if set {
+				errorf("--%s can't be used with -t/--text", name)
+			}
+		}
+		if f.canvas != "" {
+			if _, _, err := parsePair(f.canvas, "x", false); err != nil {
+				errorWithHint(fmt.Sprintf("invalid --canvas %q: %v", f.canvas, err),
+					"--canvas must be WIDTHxHEIGHT in pixels, e.g. 640x240")
+			}
+		}
+	case f.qr:
+		if f.text {
+			errorf("-t/--text can't be used with -q/--qr")
+		}
+		if !f.box && f.font.Provider != nil {
+			errorf("--font can't be used with -q/--qr unless -b/--box is given")
+		}
+		if f.size != 0 && f.printSize != 0 {
+			errorf("--size can't be used with --print-size")
+		}
+		if f.term && len(f.out) > 0 {
+			errorf("--term can't be used with -o/--output")
+		}
+		if f.term && f.format != "" {
+			errorf("--format can't be used with --term")
+		}
+		// Without an output file, the code is printed to the terminal.
+		if len(f.out) == 0 && term.IsTerminal(int(os.Stdout.Fd())) {
+			f.term = true
+		}
+		for name, set := range map[string]bool{
+			"canvas":    f.canvas != "",
+			"stroke":    f.stroke >= 0,
+			"font-size": f.fontSize != 0,
+		} {
+			if set {
+				errorf("--%s can't be used with -q/--qr", name)
+			}
+		}
+		if !f.setupFlags.Valid() {
+			warnWithHint(fmt.Sprintf("setup flags %08b have unknown bits set", f.setupFlags.Flag),
+				`known setup flags are "nfc", "ip" and "btle"`)
+		}
+	}
+
+	for name, v := range map[string]float64{
+		"font-size":  f.fontSize,
+		"dpi":        f.dpi,
+		"print-size": f.printSize,
+	} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			errorf("invalid --%s %v: must be a finite number", name, v)
+		}
+	}
+
+	if (f.text || f.qr) && len(f.out) == 0 && !f.term {
+		errorWithHint("missing output file",
+			"did you forget to specify -o/--output?")
+	}
+
+	// Infer the format from the output file extension, if not given.
+	if f.format == "" {
+		f.format = "png"
+		if strings.EqualFold(filepath.Ext(f.out), ".svg") {
+			f.format = "svg"
+		}
+	}
+	switch f.format = strings.ToLower(f.format); f.format {
+	case "png", "svg":
+	default:
+		errorWithHint(fmt.Sprintf("unknown format %q", f.format),
+			`--format must be one of "png" or "svg"`)
+	}
+
+	if f.format == "png" && !f.term && f.printSize != 0 && f.dpi == 0 {
+		errorWithHint("--print-size needs a resolution for png output",
+			"did you forget to specify --dpi, e.g. --dpi=600?")
+	}
+}
+
+// policyFlags are the flags which control the policy setup codes are checked
+// against.
+type policyFlags struct {
+	strict bool
+	weak   bool
+}
+
+func (f *policyFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.strict, "strict", true, "reject trivial setup codes")
+	fs.BoolVar(&f.weak, "reject-weak", false, "reject weak setup codes")
+}
+
+// policy returns the policy setup codes are checked against.
+func (f *policyFlags) policy() hk.Policy {
+	policy := hk.PolicyNone
+	if f.strict {
+		policy |= hk.PolicyStrict
+	}
+	if f.weak {
+		policy |= hk.PolicyWeak
+	}
+	return policy
+}
+
+// create creates the setup code and writes it to the output file.
+func (f *createFlags) create(setupCode hk.Code, setupID hk.ID) {
+	f.createInfo(hk.SetupInfo{
+		Code:     setupCode,
+		ID:       setupID,
+		Flags:    f.setupFlags.Flag,
+		Category: f.category.Category,
+	})
+}
+
+// createInfo creates the code for the setup information and writes it to the
+// output file or prints it to the terminal.
+func (f *createFlags) createInfo(info hk.SetupInfo) {
+	qrOpts, textOpts := f.options()
+
+	if f.term {
+		f.printCode(info, qrOpts)
+		return
+	}
+
+	out := newLazyOpener(f.out)
+	defer func() {
+		if err := out.Close(); err != nil {
+			errorf("failed to close output file %q: %v", f.out, err)
+		}
+	}()
+
+	err := f.write(out, info, qrOpts, textOpts)
+	if errors.Is(err, qr.ErrTooSmall) || errors.Is(err, text.ErrTooSmall) {
+		errorWithHint(fmt.Sprintf("failed to create code: %v", err),
+			"increase --print-size or, for png output, --dpi")
+	} else if err != nil {
+		errorWithPolicyHint("failed to create code", err)
+	}
+}
+
+// write writes the code in the output format to w. The renderers only fail
+// before writing anything if the code can't be created.
+func (f *createFlags) write(w io.Writer, info hk.SetupInfo, qrOpts []qr.Option, textOpts []text.Option) error {
+	switch {
+	case f.text && f.format == "svg":
+		return text.WriteSVG(w, info, textOpts...)
+	case f.text:
+		return text.WritePNG(w, info, textOpts...)
+	case f.qr && !f.box && f.format == "svg":
+		return qr.WriteSVG(w, info, qrOpts...)
+	case f.qr && !f.box:
+		return qr.WritePNG(w, info, qrOpts...)
+	case f.qr && f.box && f.format == "svg":
+		return qr.WriteBoxedSVG(w, info, qrOpts...)
+	default:
+		return qr.WriteBoxedPNG(w, info, qrOpts...)
+	}
+}
+
+// printCode prints the QR code to the terminal. Terminals supporting graphics
+// show the code as an image, others as text.
+func (f *createFlags) printCode(info hk.SetupInfo, opts []qr.Option) {
+	if g := terminal.DetectGraphics(os.Getenv); g != terminal.GraphicsNone {
+		create := qr.CreateCode
+		if f.box {
+			create = qr.CreateBoxedCode
+		}
+		img, err := create(info, opts...)
+		if err != nil {
+			errorWithPolicyHint("failed to create code", err)
+		}
+		if err := terminal.WriteImage(os.Stdout, img, g); err != nil {
+			errorf("failed to print code: %v", err)
+		}
+		return
+	}
+
+	if err := qr.WriteTerminal(os.Stdout, info, opts...); err != nil {
+		errorWithPolicyHint("failed to create code", err)
+	}
+	// Text can't show the box, but at least its digits.
+	if f.box {
+		fmt.Println(info.Code.Format())
+	}
+}
+
+// options returns the options to create qr and text codes with.
+func (f *createFlags) options() ([]qr.Option, []text.Option) {
+	qrOpts := []qr.Option{qr.WithPolicy(f.policy()), qr.WithSize(f.size)}
+	textOpts := []text.Option{text.WithPolicy(f.policy())}
+	if f.font.Provider != nil {
+		qrOpts = append(qrOpts, qr.WithFont(f.font))
+		textOpts = append(textOpts, text.WithFont(f.font))
+	}
+	if f.recovery.set {
+		qrOpts = append(qrOpts, qr.WithRecovery(f.recovery.level))
+	}
+	if f.quietZone >= 0 {
+		qrOpts = append(qrOpts, qr.WithQuietZone(f.quietZone))
+	}
+	if f.fg.Color != nil || f.bg.Color != nil {
+		qrOpts = append(qrOpts, qr.WithColors(f.fg.Color, f.bg.Color))
+	}
+	if f.canvas != "" {
+		width, height, _ := parsePair(f.canvas, "x", false)
+		textOpts = append(textOpts, text.WithCanvasSize(int(width), int(height)))
+	}
+	if f.stroke >= 0 {
+		textOpts = append(textOpts, text.WithStrokeWidth(f.stroke))
+	}
+	if f.fontSize != 0 {
+		textOpts = append(textOpts, text.WithFontSize(f.fontSize))
+	}
+	if f.dpi != 0 {
+		qrOpts = append(qrOpts, qr.WithDPI(f.dpi))
+		textOpts = append(textOpts, text.WithDPI(f.dpi))
+	}
+	if f.printSize != 0 {
+		qrOpts = append(qrOpts, qr.WithPrintSize(f.printSize))
+		textOpts = append(textOpts, text.WithPrintSize(f.printSize))
+	}
+	return qrOpts, textOpts
+}
+
+// errorWithPolicyHint exits with an error. If err is caused by a trivial setup
+// code, a hint on how to accept it anyway is given.
+func errorWithPolicyHint(msg string, err error) {
+	if errors.Is(err, hk.ErrTrivialCode) {
+		errorWithHint(fmt.Sprintf("%s: %v", msg, err),
+			"use --strict=false to accept trivial setup codes anyway")
+	}
+	errorf("%s: %v", msg, err)
+}
+
+type lazyOpener struct {
+	name string
+	f    *os.File
+	err  error
+}
+
+func newLazyOpener(name string) io.WriteCloser {
+	return &lazyOpener{name: name}
+}
+
+func (l *lazyOpener) Write(p []byte) (n int, err error) {
+	if l.f == nil && l.err == nil {
+		l.f, l.err = os.Create(l.name)
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.f.Write(p)
+}
+
+func (l *lazyOpener) Close() error {
+	if l.f != nil {
+		return l.f.Close()
+	}
+	return nil
+}
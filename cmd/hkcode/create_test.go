@@ -0,0 +1,24 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate_NonFiniteSizes(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "code.svg")
+
+	for _, args := range [][]string{
+		{"--text", "--print-size=Inf", "-o", out, "12344321"},
+		{"--text", "--print-size=NaN", "-o", out, "12344321"},
+		{"--text", "--font-size=NaN", "-o", out, "12344321"},
+		{"--qr", "-i", "RFGD", "--dpi=-Inf", "-o", out, "12344321"},
+	} {
+		got, err := runHKCode(t, args...)
+		assert.Error(t, err, args)
+		assert.Contains(t, got, "must be a finite number", args)
+		assert.NoFileExists(t, out, args)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+func decode(args []string) {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		imageFlag    string
+		deviceIDFlag string
+	)
+	fs.StringVar(&imageFlag, "I", "", "decode QR code image from `FILE`")
+	fs.StringVar(&imageFlag, "image", "", "decode QR code image from `FILE`")
+	fs.StringVar(&deviceIDFlag, "d", "", "device id")
+	fs.StringVar(&deviceIDFlag, "device-id", "", "device id")
+
+	_ = fs.Parse(args)
+
+	var deviceID *hk.DeviceID
+	if deviceIDFlag != "" {
+		id := parseDeviceID(deviceIDFlag)
+		deviceID = &id
+	}
+
+	if imageFlag != "" {
+		if fs.NArg() > 0 {
+			errorf("-I/--image can't be used with a payload argument")
+		}
+		payload, err := scanImageFile(imageFlag)
+		if err != nil {
+			errorf("failed to decode image %q: %v", imageFlag, err)
+		}
+		printPayload(os.Stdout, payload, deviceID)
+		return
+	}
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the payload must be specified after all flags")
+	}
+
+	payloadStr := strings.TrimSpace(argOrStdin(fs.Arg(0), "payload"))
+	if payloadStr == "" {
+		errorWithHint("payload is empty",
+			"set it as the last argument after all flags or pipe it via stdin")
+	}
+
+	payload, err := qr.ParsePayload(payloadStr)
+	if err != nil {
+		errorf("failed to parse payload: %v", err)
+	}
+
+	printPayload(os.Stdout, payload, deviceID)
+}
+
+func scanImageFile(name string) (qr.Payload, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return qr.Payload{}, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return qr.Payload{}, err
+	}
+
+	return qr.ScanImage(img)
+}
+
+// printPayload prints the payload. If deviceID is not nil, it is printed along
+// with the resulting setup hash.
+func printPayload(w io.Writer, payload qr.Payload, deviceID *hk.DeviceID) {
+	setupFlags := payload.Flags.String()
+	if !payload.Flags.Valid() {
+		warnWithHint(fmt.Sprintf("setup flags %04b have unknown bits set", payload.Flags))
+		setupFlags = fmt.Sprintf("%04b", payload.Flags)
+	} else if setupFlags == "" {
+		setupFlags = "<none>"
+	}
+
+	fmt.Fprintf(w, "Setup Code:  %s\n", payload.Code.Format())
+	fmt.Fprintf(w, "Setup ID:    %s\n", payload.ID)
+	fmt.Fprintf(w, "Setup Flags: %s\n", setupFlags)
+	fmt.Fprintf(w, "Category:    %s\n", payload.Category)
+	fmt.Fprintf(w, "Version:     %d\n", payload.Version)
+	fmt.Fprintf(w, "Reserved:    %d\n", payload.Reserved)
+	if deviceID != nil {
+		fmt.Fprintf(w, "Device ID:   %s\n", deviceID)
+		fmt.Fprintf(w, "Setup Hash:  %s\n", hk.SetupHash(payload.ID, *deviceID))
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func derive(args []string) {
+	fs := flag.NewFlagSet("derive", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		createFlags  createFlags
+		keyFileFlag  string
+		serialFlag   string
+		versionFlag  int
+		deviceIDFlag string
+	)
+	createFlags.register(fs)
+	fs.StringVar(&keyFileFlag, "k", "", "read master key from `FILE`")
+	fs.StringVar(&keyFileFlag, "key-file", "", "read master key from `FILE`")
+	fs.StringVar(&serialFlag, "serial", "", "accessory serial number")
+	fs.IntVar(&versionFlag, "version", hk.DeriveVersion, "derivation scheme `VERSION`")
+	fs.StringVar(&deviceIDFlag, "d", "", "device id")
+	fs.StringVar(&deviceIDFlag, "device-id", "", "device id")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"the setup code is derived, use --serial to specify the accessory")
+	}
+	if keyFileFlag == "" {
+		errorWithHint("missing master key file",
+			"did you forget to specify -k/--key-file?")
+	}
+	if serialFlag == "" {
+		errorWithHint("missing serial number",
+			"did you forget to specify --serial?")
+	}
+	if createFlags.setupID != "" {
+		errorf("-i/--id can't be used with derive")
+	}
+	if createFlags.text && deviceIDFlag != "" {
+		errorf("-d/--device-id can't be used with -t/--text")
+	}
+	createFlags.validate()
+
+	info, err := hk.DeriveSetupInfoVersion(readMasterKey(keyFileFlag), serialFlag, versionFlag)
+	if err != nil {
+		errorf("failed to derive setup code: %v", err)
+	}
+	info.Flags = createFlags.setupFlags.Flag
+	info.Category = createFlags.category.Category
+
+	if createFlags.text || createFlags.qr {
+		createFlags.createInfo(info)
+	}
+
+	fmt.Fprintf(os.Stdout, "Serial:      %s\n", info.SerialNumber)
+	fmt.Fprintf(os.Stdout, "Setup Code:  %s\n", info.Code.Format())
+	fmt.Fprintf(os.Stdout, "Setup ID:    %s\n", info.ID)
+	if deviceIDFlag != "" {
+		deviceID := parseDeviceID(deviceIDFlag)
+		fmt.Fprintf(os.Stdout, "Device ID:   %s\n", deviceID)
+		fmt.Fprintf(os.Stdout, "Setup Hash:  %s\n", hk.SetupHash(info.ID, deviceID))
+	}
+}
+
+// readMasterKey reads the master key from the file at path name. Files with
+// hex encoded contents are decoded, surrounding whitespace ignored, others are
+// used as is.
+func readMasterKey(name string) []byte {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		errorf("failed to read master key: %v", err)
+	}
+	if key, err := hex.DecodeString(string(bytes.TrimSpace(b))); err == nil {
+		return key
+	}
+	return b
+}
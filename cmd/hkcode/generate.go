@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func generate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		createFlags   createFlags
+		registryFlags registryFlags
+		deviceIDFlag  string
+		serialFlag    string
+	)
+	createFlags.register(fs)
+	registryFlags.register(fs, true)
+	fs.StringVar(&deviceIDFlag, "d", "", "device id")
+	fs.StringVar(&deviceIDFlag, "device-id", "", "device id")
+	fs.StringVar(&serialFlag, "serial", "", "accessory serial number")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the setup code must be specified after all flags")
+	}
+	createFlags.validate()
+	if createFlags.text && deviceIDFlag != "" {
+		errorf("-d/--device-id can't be used with -t/--text")
+	}
+
+	var (
+		setupCode hk.Code
+		err       error
+	)
+	if arg := fs.Arg(0); arg != "" {
+		setupCode = parseSetupCode(arg)
+		if err := createFlags.policy().Check(setupCode); err != nil {
+			errorWithPolicyHint("failed to generate setup code", err)
+		}
+	} else if setupCode, err = createFlags.policy().GenerateCode(nil); err != nil {
+		errorf("failed to generate setup code: %v", err)
+	}
+
+	var setupID hk.ID
+	if createFlags.setupID != "" {
+		setupID = parseSetupID(createFlags.setupID)
+	} else if !createFlags.text {
+		if setupID, err = hk.GenerateID(nil); err != nil {
+			errorf("failed to generate setup id: %v", err)
+		}
+	}
+
+	var deviceID hk.DeviceID
+	if deviceIDFlag != "" {
+		deviceID = parseDeviceID(deviceIDFlag)
+	} else if !createFlags.text {
+		if deviceID, err = hk.GenerateDeviceID(nil); err != nil {
+			errorf("failed to generate device id: %v", err)
+		}
+	}
+
+	info := hk.SetupInfo{
+		Code:         setupCode,
+		ID:           setupID,
+		Flags:        createFlags.setupFlags.Flag,
+		Category:     createFlags.category.Category,
+		SerialNumber: serialFlag,
+	}
+	if setupID != "" {
+		info.DeviceID = &deviceID
+	}
+
+	// Issue the setup code through the registry, so it is never issued twice.
+	// Generated values are generated again if they are registered already.
+	reg := registryFlags.open()
+	if reg != nil {
+		if err := reserve(reg, &info, createFlags.policy(), fs.Arg(0) == "", createFlags.setupID == ""); err != nil {
+			errorWithHint(fmt.Sprintf("failed to issue setup code: %v", err),
+				"use 'hkcode registry reprint' to create the code of a registered accessory again")
+		}
+	}
+
+	if createFlags.text || createFlags.qr {
+		createFlags.createInfo(info)
+	}
+
+	if reg != nil {
+		if err := reg.Add(registryFlags.record(info)); err != nil {
+			errorf("failed to register setup code: %v", err)
+		} else if err := reg.Close(); err != nil {
+			errorf("failed to close registry: %v", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "Setup Code:  %s\n", info.Code.Format())
+	if info.ID != "" {
+		fmt.Fprintf(os.Stdout, "Setup ID:    %s\n", info.ID)
+		fmt.Fprintf(os.Stdout, "Device ID:   %s\n", deviceID)
+		fmt.Fprintf(os.Stdout, "Setup Hash:  %s\n", hk.SetupHash(info.ID, deviceID))
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Policy(t *testing.T) {
+	out, err := runHKCode(t, "generate", "11111111")
+	assert.Error(t, err)
+	assert.Contains(t, out, "failed to generate setup code")
+	assert.Contains(t, out, "--strict=false")
+
+	out, err = runHKCode(t, "generate", "--reject-weak", "12344321")
+	assert.Error(t, err)
+	assert.Contains(t, out, "failed to generate setup code")
+
+	out, err = runHKCode(t, "generate", "--strict=false", "11111111")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "111-11-111")
+
+	out, err = runHKCode(t, "generate", "12344321")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "123-44-321")
+}
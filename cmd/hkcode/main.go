@@ -1,64 +1,452 @@
 package main
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
-	"image"
-	"image/png"
+	"image/color"
 	"io"
 	"log"
 	"os"
 	"runtime/debug"
-	"strconv"
 	"strings"
 
+	"github.com/skip2/go-qrcode"
+
 	"github.com/lukasmalkmus/hkcode/hk"
-	"github.com/lukasmalkmus/hkcode/hk/qr"
-	"github.com/lukasmalkmus/hkcode/hk/text"
+	hkfont "github.com/lukasmalkmus/hkcode/hk/font"
 )
 
 const usage = `Usage:
-    hkcode --text [-o OUTPUT] [SETUP_CODE]
+    hkcode --text [--strict BOOL] [--reject-weak BOOL] [--format FORMAT]
+           [--font FONT] [--canvas SIZE] [--stroke PIXELS]
+           [--font-size PIXELS] [--dpi DPI] [--print-size MM] [-o OUTPUT]
+           [SETUP_CODE]
     hkcode --qr [-b BOOL] [-i SETUP_ID] [-f SETUP_FLAG]... [-c CATEGORY]
-           [-o OUTPUT] [SETUP_CODE]
+           [--strict BOOL] [--reject-weak BOOL] [--format FORMAT]
+           [--font FONT] [--size PIXELS] [--recovery LEVEL]
+           [--quiet-zone MODULES] [--fg COLOR] [--bg COLOR] [--dpi DPI]
+           [--print-size MM] [-o OUTPUT | --term BOOL] [SETUP_CODE]
+    hkcode generate [-d DEVICE_ID] [--serial SERIAL] [--registry PATH]
+           [--register BOOL] [--operator NAME] [--text|--qr ...] [SETUP_CODE]
+    hkcode derive -k KEY_FILE --serial SERIAL [--version VERSION]
+           [-d DEVICE_ID] [--text|--qr ...]
+    hkcode provision [--strict BOOL] [--reject-weak BOOL] [-o OUTPUT]
+           [SETUP_CODE]
+    hkcode pairtest -s SECRETS [-d DEVICE_ID] [SETUP_CODE]
+    hkcode sheet [--layout LAYOUT] [--page-size SIZE] [--margins MARGINS]
+           [--label-size SIZE] [--pitch PITCH] [--grid GRID] [--padding MM]
+           [--crop-marks BOOL] [--bleed MM] [-b BOOL] [--caption BOOL]
+           [--font FONT] [--strict BOOL] [--reject-weak BOOL] -o OUTPUT [INPUT]
+    hkcode batch [--text|--qr ...] [--name TEMPLATE] [--input-format FORMAT]
+           [-j JOBS] [--registry PATH] [--register BOOL] [--operator NAME]
+           [--encrypt-to PUBLIC_KEY] [--sign-key PRIVATE_KEY] [-o DIRECTORY]
+           [BATCH_INPUT]
+    hkcode manifest verify [-k PRIVATE_KEY] [--sign-pub PUBLIC_KEY] [MANIFEST]
+    hkcode manifest decrypt -k PRIVATE_KEY [--sign-pub PUBLIC_KEY] [-o OUTPUT]
+           MANIFEST
+    hkcode manifest keygen --type TYPE -o OUTPUT
+    hkcode registry list [--registry PATH]
+    hkcode registry search [--registry PATH] QUERY
+    hkcode registry reprint [--registry PATH] [--text|--qr ...] KEY
+    hkcode decode [-d DEVICE_ID] [PAYLOAD]
+    hkcode decode [-d DEVICE_ID] -I IMAGE
 
 Options:
     -t, --text               Create a text based Apple HomeKit® setup code.
     -q, --qr                 Create a QR code based Apple HomeKit® setup code.
     -o, --output OUTPUT      Write the result to the file at path OUTPUT.
+    --term BOOL              Print the QR code to the terminal instead. It is
+                             shown as an image if the terminal supports the
+                             kitty or sixel graphics protocol, else drawn with
+                             text. Defaults to true for QR codes if OUTPUT is
+                             not given and standard output is a terminal.
+    --format FORMAT          Format of OUTPUT, one of "png" or "svg". Defaults
+                             to "svg" if OUTPUT ends with ".svg", else "png".
     -b, --box BOOL           Box the QR code with a text code and the Apple
                              HomeKit® logo. Optional.
     -i, --id SETUP_ID        Four character setup id.
     -f, --flag SETUP_FLAG    Describes the accessories supported pairing
                              methods. Optional.
     -c, --category CATEGORY  Category of the accessory. Optional.
-    
-If SETUP_CODE is ommited as an argument, it will default to standard input. Must
-be a number between 0 and 99999999, no padding required. Trivial setup codes are
-accepted but not recommended.
-
-If OUTPUT exists, it will be overwritten. OUTPUT is png encoded.
+    --font FONT              Font of the text code and the digits of the boxed
+                             QR code. Optional.
+    --size PIXELS            Width of the QR code. Defaults to 256 or, if boxed,
+                             400.
+    --recovery LEVEL         Error recovery level of the QR code, one of "low",
+                             "medium", "high" or "highest". Defaults to "high"
+                             or, if boxed, "medium".
+    --quiet-zone MODULES     Width of the empty border around the QR code.
+                             Defaults to 4 or, if boxed, 0.
+    --fg COLOR               Color of the QR code modules and, if boxed, the
+                             digits, frame and logo. Optional.
+    --bg COLOR               Background color of the QR code. Optional.
+    --canvas SIZE            Size of the text code as WIDTHxHEIGHT. Defaults to
+                             160x60.
+    --stroke PIXELS          Width of the text code border. Defaults to 2.
+    --font-size PIXELS       Font size of the text code. Defaults to 20.
+    --dpi DPI                Resolution the code is printed at in dots per
+                             inch. Stored in png files. Optional.
+    --print-size MM          Width of the printed code in millimetres. The size
+                             in pixels follows from --dpi, which is required for
+                             png output. Codes too small to be scanned reliably
+                             are rejected. Optional.
+    --strict BOOL            Reject trivial setup codes which are forbidden by
+                             the HomeKit Accessory Protocol Specification.
+                             Defaults to true.
+    --reject-weak BOOL       Reject setup codes with easily guessable patterns
+                             like repeated pairs, short cycles, palindromes and
+                             dates. Optional.
+
+Commands:
+    generate                 Generate a random setup code and setup id using a
+                             cryptographically secure random source and print
+                             them. Only the ones not given are generated. If
+                             -t/--text or -q/--qr is given, the setup code is
+                             created as well and all options from above apply.
+                             Unless -t/--text is given, a device id is
+                             generated as well and the setup hash is printed.
+                             The setup code is issued through the registry.
+    derive                   Derive the setup code and setup id of the
+                             accessory with serial number SERIAL from the
+                             master key in KEY_FILE and print them. The same
+                             key and serial number always result in the same
+                             setup code, so it can be recreated instead of
+                             being stored. If -t/--text or -q/--qr is given,
+                             the setup code is created as well and all options
+                             from above apply, except -i/--id.
+    provision                Create the SRP salt and verifier accessories store
+                             instead of the plain setup code. They are printed
+                             hex encoded or, if -o/--output is given, written
+                             to OUTPUT as JSON.
+    pairtest                 Simulate the pair setup procedure (M1-M6) between
+                             a controller knowing SETUP_CODE and an accessory
+                             knowing the salt and verifier from SECRETS, as
+                             written by provision. Fails if they don't match.
+    sheet                    Create a PDF document with a sheet of QR code
+                             labels, one for each setup information in INPUT,
+                             for printing on label stock. As many pages as
+                             needed are created.
+    batch                    Create a code for each row of BATCH_INPUT and
+                             write them to files in DIRECTORY, which defaults
+                             to the current directory. The options of
+                             -t/--text and -q/--qr apply to all codes. Rows
+                             which fail are reported and the others are still
+                             created. A manifest of the created codes is
+                             written to DIRECTORY as manifest.csv and
+                             manifest.json. The setup codes are issued through
+                             the registry.
+    manifest verify          Verify the files recorded in the manifest at path
+                             MANIFEST, which defaults to manifest.json. Their
+                             SHA-256 hashes are compared and the payloads and
+                             setup hashes derived again from the setup
+                             information to detect tampering and partial
+                             writes. Manifests ending with ".csv" are read as
+                             CSV. Encrypted manifests, ending with ".box", are
+                             decrypted first.
+    manifest decrypt         Decrypt the encrypted manifest at path MANIFEST
+                             and print it or, if -o/--output is given, write it
+                             to OUTPUT, readable only by its owner.
+    manifest keygen          Create a key pair for encrypting or signing
+                             manifests. The private key is written to OUTPUT,
+                             which must not exist, and the public key to
+                             OUTPUT.pub, both hex encoded.
+    registry list            List the setup codes issued through the registry.
+    registry search          List the issued setup codes whose serial number
+                             or operator contains QUERY or whose setup code or
+                             setup id is QUERY.
+    registry reprint         Create the code of an issued setup code again,
+                             without issuing a new one. KEY is its serial
+                             number, setup code or setup id. The options of
+                             -t/--text and -q/--qr apply, except -i/--id,
+                             -f/--flag and -c/--category.
+    decode                   Decode an Apple HomeKit® setup payload of the
+                             form X-HM://... and print the setup information.
+
+Pairtest options:
+    -s, --secrets SECRETS    Read the salt and verifier from the file at path
+                             SECRETS.
+
+Sheet options:
+    --layout LAYOUT          Label sheet layout, one of "avery-l7651" (A4,
+                             65 labels), "avery-5160" (US Letter, 30 labels)
+                             or "square-30mm" (A4, 54 labels). Defaults to
+                             "avery-l7651". The options below override it.
+    --page-size SIZE         Page size, one of "a4", "a5", "letter" or
+                             WIDTHxHEIGHT in millimetres.
+    --margins MARGINS        Distance of the first label from the top and left
+                             page edge as TOP,LEFT in millimetres.
+    --label-size SIZE        Label size as WIDTHxHEIGHT in millimetres.
+    --pitch PITCH            Distance between the top left corners of adjacent
+                             labels as XxY in millimetres.
+    --grid GRID              Number of labels per page as COLUMNSxROWS.
+    --padding MM             Minimum distance of the code from the label edges.
+    --crop-marks BOOL        Print marks at the label corners to guide
+                             cutting. Optional.
+    --bleed MM               Extend a white label background beyond the label
+                             edges by MM millimetres. Optional.
+    -b, --box BOOL           Use boxed QR codes. Optional.
+    --caption BOOL           Print the serial number, if any, below each code.
+                             Defaults to true.
+    --font FONT              Font of the serial numbers and the digits of boxed
+                             QR codes. Optional.
+
+Batch options:
+    --name TEMPLATE          Go template of the file names, relative to
+                             DIRECTORY. The fields .Serial, .Code, .ID, .Flags,
+                             .Category and .Line are available. Defaults to
+                             "{{.Serial}}-{{.ID}}.png". Codes are svg encoded
+                             if it ends with ".svg".
+    --input-format FORMAT    Format of BATCH_INPUT, one of "csv" or "jsonl".
+                             Defaults to the file extension or, for standard
+                             input, the first row.
+    -j, --jobs JOBS          Number of codes created in parallel. Defaults to
+                             the number of CPUs.
+    --encrypt-to PUBLIC_KEY  Encrypt the manifests to the recipient whose
+                             public key is in the file at path PUBLIC_KEY, an
+                             "encrypt" key. They are written with the
+                             extension ".box" instead of in plain text. Only
+                             the holder of the private key can read them.
+    --sign-key PRIVATE_KEY   Sign the manifests, as written, with the "sign"
+                             key in the file at path PRIVATE_KEY. The
+                             signatures are written next to them with the
+                             extension ".sig". Manifests and signatures left
+                             in DIRECTORY by earlier runs are replaced or
+                             removed.
+
+Manifest options:
+    -k, --key-file PRIVATE_KEY
+                             Decrypt encrypted manifests with the "encrypt" key
+                             in the file at path PRIVATE_KEY.
+    --sign-pub PUBLIC_KEY    Check the signature of the manifest with the
+                             public "sign" key in the file at path PUBLIC_KEY
+                             before reading it. Fails if it is missing or the
+                             manifest was altered. Required if the manifest
+                             has a signature. Defaults to $HKCODE_SIGN_PUB, so
+                             signatures can always be required. Without it,
+                             the signature is still required if the other
+                             manifest of the batch is signed.
+    --type TYPE              Type of the key pair, "encrypt" for NaCl box keys
+                             or "sign" for Ed25519 keys.
+
+Derive options:
+    -k, --key-file KEY_FILE  Read the master key from the file at path
+                             KEY_FILE. Hex encoded keys are decoded, others are
+                             used as is. It must have at least 16 bytes, 32
+                             random bytes are recommended.
+    --serial SERIAL          Serial number of the accessory.
+    --version VERSION        Version of the derivation scheme. Defaults to the
+                             latest, 1. Older versions recreate setup codes
+                             derived before the scheme changed.
+
+Generate and batch options:
+    --serial SERIAL          Serial number of the accessory. Generate only.
+    --register BOOL          Issue the setup codes through the registry, which
+                             rejects setup codes, setup ids and serial numbers
+                             issued before. Generated ones are generated again
+                             instead. The serial number, time and operator are
+                             recorded. Defaults to true.
+    --operator NAME          Operator recorded in the registry. Defaults to
+                             $HKCODE_OPERATOR or the current user.
+
+Registry options:
+    --registry PATH          Path of the registry, an append-only log locked
+                             while in use. Defaults to $HKCODE_REGISTRY or
+                             hkcode/registry.jsonl in the user's config
+                             directory.
+
+Generate, derive, pairtest and decode options:
+    -d, --device-id DEVICE_ID
+                             Accessory identifier of the form XX:XX:XX:XX:XX:XX
+                             used to compute the setup hash advertised via
+                             Bonjour and as the accessory's pairing identifier.
+
+Decode options:
+    -I, --image IMAGE        Read the payload from the QR code in the png or
+                             jpeg encoded image at path IMAGE instead.
 
-SETUP_FLAG is one of "nfc", "ip" or "btle".
+If SETUP_CODE is ommited as an argument, it will default to standard input. Must
+be a number between 0 and 99999999, no padding required, or all 8 digits in the
+form XXX-XX-XXX or XXXX XXXX. Trivial setup codes
+(00000000, 11111111, ..., 99999999, 12345678 and 87654321) are rejected unless
+--strict=false is given.
+
+If INPUT is ommited as an argument, it will default to standard input. It has
+one setup information per line, either as a JSON object like
+{"code":"12344321","id":"MHKA","category":"outlet","serial_number":"SN1"} or in
+the form code=12344321&id=MHKA&category=outlet&serial_number=SN1. Empty lines and
+lines starting with "#" are skipped.
+
+If BATCH_INPUT is ommited as an argument, it will default to standard input.
+CSV input has a header row naming the columns, JSON Lines input one JSON
+object per line. The columns or keys are "serial" (or "serial_number"),
+"code", "id", "flags", "category" and "device_id", all optional. Missing setup
+codes and setup ids are generated, like device ids of QR codes, and flags and
+category default to the -f/--flag and -c/--category options. In JSON Lines
+input, "code" and "category" may also be numbers, like {"code":12344321}. In
+file names, the serial number defaults to the line number.
+
+If PAYLOAD is ommited as an argument, it will default to standard input.
+
+If OUTPUT exists, it will be overwritten. OUTPUT is png encoded unless the svg
+format is used. SVG codes are drawn with vector shapes only, the digits included,
+and stay sharp at any size.
+
+SETUP_FLAG is one of "nfc", "ip" or "btle". Multiple flags can be given by
+repeating the option or as a list separated by "|" or ",", e.g. "ip|btle".
+
+FONT is the name of a built-in font, one of "sf-mono-bold", "scancardium",
+"go-mono" or "go-mono-bold", or the path of a TrueType or OpenType font file.
+By default, the fonts used by Apple are used: SF Mono Bold for QR codes and
+Scancardium for text codes. Their licenses only allow redistribution on macOS,
+so the Go fonts are used elsewhere.
+
+COLOR is of the form #RRGGBB or #RRGGBBAA or one of "black", "white" or
+"transparent".
 
 CATEGORY is one of the following: other, bridge, fan, garage_door_opener,
 lightbulb, door_lock, outlet, switch, thermostat, sensor, security_system, door,
-window, window_covering, programmable_switch, ip_camera, video_doorbell,
-air_purifier, heater, air_conditioner, humidifier, dehumidifier, sprinklers,
-faucets, shower_systems.
+window, window_covering, programmable_switch, range_extender, ip_camera,
+video_doorbell, air_purifier, heater, air_conditioner, humidifier,
+dehumidifier, apple_tv, homepod, speaker, airport, sprinklers, faucets,
+shower_systems, television, remote_control, router, audio_receiver,
+tv_set_top_box, tv_streaming_stick. Kebab case ("garage-door-opener"), the
+display name ("Garage Door Opener") and the category number ("4") are accepted
+as well.
 
 Example:
     $ hkcode --text -o=code.png 12344321
     $ hkcode --qr -o=code.png -i=MHKA -f=ip -f=btle -c=outlet 12344321
-    $ shuf -i 1-99999999 -n 1 | hkcode --qr -b -o=code.png -i=MHKA -f=ip -c=switch
+    $ hkcode --qr -b -o=code.svg -i=MHKA -c=outlet 12344321
+    $ hkcode --qr --size=2048 --recovery=highest -o=code.png -i=MHKA 12344321
+    $ hkcode --qr -b --print-size=15 --dpi=600 -o=code.png -i=MHKA 12344321
+    $ hkcode --qr --term -i=MHKA 12344321
+    $ hkcode generate --qr -b -o=code.png -f=ip -c=switch
+    $ hkcode derive -k=master.key --serial=SN1 --qr -o=code.png
+    $ hkcode provision -o=secrets.json 12344321
+    $ hkcode pairtest -s=secrets.json 12344321
+    $ hkcode sheet --crop-marks -o=sheet.pdf codes.jsonl
+    $ hkcode batch --qr -b -o=codes --name="{{.Serial}}.svg" codes.csv
+    $ hkcode manifest verify codes/manifest.json
+    $ hkcode manifest keygen --type=encrypt -o=office.key
+    $ hkcode manifest keygen --type=sign -o=factory.key
+    $ hkcode batch --qr --encrypt-to=office.key.pub --sign-key=factory.key \
+        -o=codes codes.csv
+    $ hkcode manifest verify -k=office.key --sign-pub=factory.key.pub \
+        codes/manifest.json.box
+    $ hkcode registry search SN1
+    $ hkcode registry reprint --qr -b -o=code.png SN1
+    $ hkcode decode X-HM://00857FT35MHKA
+    $ hkcode decode --image=code.png
 `
 
-type multiFlag []string
+type setupFlagFlag struct {
+	hk.Flag
+}
+
+func (f setupFlagFlag) String() string { return f.Flag.String() }
+
+// Set adds the parsed flags to the ones already set, so the flag can be given
+// multiple times.
+func (f *setupFlagFlag) Set(value string) error {
+	flag, err := hk.ParseFlag(value)
+	if err != nil {
+		return err
+	}
+	f.Flag |= flag
+	return nil
+}
+
+type fontFlag struct {
+	hkfont.Provider
+	value string
+}
 
-func (f *multiFlag) String() string { return fmt.Sprint(*f) }
+func (f fontFlag) String() string { return f.value }
+
+// Set uses the built-in font of the given name or loads the font file at the
+// given path.
+func (f *fontFlag) Set(value string) error {
+	p, ok := hkfont.Lookup(value)
+	if !ok {
+		var err error
+		if p, err = hkfont.Load(value); err != nil {
+			return err
+		}
+	}
+	if _, err := p.Font(); err != nil {
+		return err
+	}
+	f.Provider, f.value = p, value
+	return nil
+}
 
-func (f *multiFlag) Set(value string) error {
-	*f = append(*f, value)
+type recoveryFlag struct {
+	level qrcode.RecoveryLevel
+	set   bool
+}
+
+var recoveryLevels = map[string]qrcode.RecoveryLevel{
+	"low":     qrcode.Low,
+	"medium":  qrcode.Medium,
+	"high":    qrcode.High,
+	"highest": qrcode.Highest,
+}
+
+func (f recoveryFlag) String() string {
+	for name, level := range recoveryLevels {
+		if f.set && level == f.level {
+			return name
+		}
+	}
+	return ""
+}
+
+func (f *recoveryFlag) Set(value string) error {
+	level, ok := recoveryLevels[strings.ToLower(value)]
+	if !ok {
+		return fmt.Errorf(`unknown recovery level %q, want one of "low", "medium", "high" or "highest"`, value)
+	}
+	f.level, f.set = level, true
+	return nil
+}
+
+type colorFlag struct {
+	color.Color
+}
+
+func (f colorFlag) String() string {
+	if f.Color == nil {
+		return ""
+	}
+	c := color.NRGBAModel.Convert(f.Color).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// Set parses a color of the form #RRGGBB or #RRGGBBAA or one of the names
+// "black", "white" and "transparent".
+func (f *colorFlag) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "black":
+		f.Color = color.Black
+		return nil
+	case "white":
+		f.Color = color.White
+		return nil
+	case "transparent":
+		f.Color = color.Transparent
+		return nil
+	}
+
+	hexValue := strings.TrimPrefix(value, "#")
+	if len(hexValue) == 6 {
+		hexValue += "ff"
+	}
+	b, err := hex.DecodeString(hexValue)
+	if err != nil || len(b) != 4 {
+		return fmt.Errorf("invalid color %q, want #RRGGBB, #RRGGBBAA, black, white or transparent", value)
+	}
+	f.Color = color.NRGBA{R: b[0], G: b[1], B: b[2], A: b[3]}
 	return nil
 }
 
@@ -68,14 +456,9 @@ type categoryFlag struct {
 
 func (f categoryFlag) String() string { return f.Category.String() }
 
-func (f *categoryFlag) Set(value string) error {
-	for c := hk.CategoryUnknown; c <= hk.CategoryShowerSystems; c++ {
-		if strings.EqualFold(c.String(), value) {
-			*f = categoryFlag{c}
-			return nil
-		}
-	}
-	return fmt.Errorf("unknown category %q", value)
+func (f *categoryFlag) Set(value string) (err error) {
+	f.Category, err = hk.ParseCategory(value)
+	return err
 }
 
 var version string
@@ -85,32 +468,45 @@ func main() {
 
 	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "decode":
+			decode(os.Args[2:])
+			return
+		case "generate":
+			generate(os.Args[2:])
+			return
+		case "provision":
+			provision(os.Args[2:])
+			return
+		case "pairtest":
+			pairtest(os.Args[2:])
+			return
+		case "sheet":
+			sheet(os.Args[2:])
+			return
+		case "batch":
+			batch(os.Args[2:])
+			return
+		case "manifest":
+			manifestCmd(os.Args[2:])
+			return
+		case "registry":
+			registryCmd(os.Args[2:])
+			return
+		case "derive":
+			derive(os.Args[2:])
+			return
+		}
+	}
+
 	var (
-		versionFlag   bool
-		textFlag      bool
-		qrFlag        bool
-		outFlag       string
-		boxFlag       bool
-		setupIDFlag   string
-		setupFlagFlag multiFlag
-		categoryFlag  categoryFlag
+		versionFlag bool
+		createFlags createFlags
 	)
 
 	flag.BoolVar(&versionFlag, "version", false, "print the version")
-	flag.BoolVar(&textFlag, "t", false, "create text code")
-	flag.BoolVar(&textFlag, "text", false, "create text code")
-	flag.BoolVar(&qrFlag, "q", false, "create qr code")
-	flag.BoolVar(&qrFlag, "qr", false, "create qr code")
-	flag.StringVar(&outFlag, "o", "", "output to `FILE`")
-	flag.StringVar(&outFlag, "output", "", "output to `FILE`")
-	flag.BoolVar(&boxFlag, "b", false, "create boxed qr code")
-	flag.BoolVar(&boxFlag, "box", false, "create boxed qr code")
-	flag.StringVar(&setupIDFlag, "i", "", "setup id")
-	flag.StringVar(&setupIDFlag, "id", "", "setup id")
-	flag.Var(&setupFlagFlag, "f", "supported pairing methods")
-	flag.Var(&setupFlagFlag, "flag", "supported pairing methods")
-	flag.Var(&categoryFlag, "c", "accessory category")
-	flag.Var(&categoryFlag, "category", "accessory category")
+	createFlags.register(flag.CommandLine)
 
 	flag.Parse()
 
@@ -132,108 +528,70 @@ func main() {
 			"note that the setup code must be specified after all flags")
 	}
 
-	switch {
-	case textFlag:
-		if qrFlag {
-			errorf("-q/--qr can't be used with -t/--text")
-		}
-		if boxFlag {
-			errorf("-b/--box can't be used with -t/--text")
-		}
-		if len(setupIDFlag) > 0 {
-			errorf("-i/--id can't be used with -t/--text")
-		}
-		if len(setupFlagFlag) > 0 {
-			errorf("-f/--flag can't be used with -t/--text")
-		}
-		if categoryFlag.Category > 0 {
-			errorf("-c/--category can't be used with -t/--text")
-		}
-	case qrFlag:
-		if textFlag {
-			errorf("-t/--text can't be used with -q/--qr")
-		}
-	default:
+	if !createFlags.text && !createFlags.qr {
 		errorWithHint("missing mode",
 			"did you forget to specify one of -t/--text or -q/--qr?")
 	}
+	createFlags.validate()
 
-	if len(outFlag) == 0 {
-		errorWithHint("missing output file",
-			"did you forget to specify -o/--output?")
-	}
+	setupCode := parseSetupCode(argOrStdin(flag.Arg(0), "setup code"))
 
-	var setupCodeStr string
-	if setupCodeStr = flag.Arg(0); setupCodeStr == "" || setupCodeStr == "-" {
-		setupCode, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			errorf("failed to read setup code from stdin: %v", err)
-		}
-		setupCodeStr = strings.TrimSuffix(string(setupCode), "\n")
+	var setupID hk.ID
+	if createFlags.qr {
+		setupID = parseSetupID(createFlags.setupID)
 	}
 
-	if setupCodeStr == "" {
+	createFlags.create(setupCode, setupID)
+}
+
+// parseSetupCode parses the setup code given as an argument or via stdin.
+func parseSetupCode(s string) hk.Code {
+	if s == "" {
 		errorWithHint("setup code is empty",
 			"set it as the last argument after all flags or pipe it via stdin")
 	}
 
-	var setupCode hk.Code
-	setupCode64, err := strconv.ParseUint(setupCodeStr, 10, 32)
+	setupCode, err := hk.ParseCode(s)
 	if err != nil {
 		errorf("failed to parse setup code: %v", err)
 	}
-	setupCode = hk.Code(setupCode64)
+	return setupCode
+}
 
-	out := newLazyOpener(outFlag)
-	defer func() {
-		if err := out.Close(); err != nil {
-			errorf("failed to close output file %q: %v", outFlag, err)
-		}
-	}()
-
-	var outImg image.Image
-	switch {
-	case textFlag:
-		outImg, err = text.CreateCode(setupCode)
-	case qrFlag && !boxFlag:
-		outImg, err = qr.CreateCode(setupCode, hk.ID(setupIDFlag), hk.FlagNone, categoryFlag.Category)
-	case qrFlag && boxFlag:
-		outImg, err = qr.CreateBoxedCode(setupCode, hk.ID(setupIDFlag), hk.FlagNone, categoryFlag.Category)
-	}
-	if err != nil {
-		errorf("failed to create code: %v", err)
+// parseSetupID parses the setup id given as a flag.
+func parseSetupID(s string) hk.ID {
+	if s == "" {
+		errorWithHint("setup id is empty",
+			"did you forget to specify -i/--id?")
 	}
 
-	if err := png.Encode(out, outImg); err != nil {
-		errorf("failed to encode code: %v", err)
+	setupID, err := hk.ParseID(s)
+	if err != nil {
+		errorf("failed to parse setup id: %v", err)
 	}
+	return setupID
 }
 
-type lazyOpener struct {
-	name string
-	f    *os.File
-	err  error
-}
-
-func newLazyOpener(name string) io.WriteCloser {
-	return &lazyOpener{name: name}
-}
-
-func (l *lazyOpener) Write(p []byte) (n int, err error) {
-	if l.f == nil && l.err == nil {
-		l.f, l.err = os.Create(l.name)
-	}
-	if l.err != nil {
-		return 0, l.err
+// parseDeviceID parses the device id given as a flag.
+func parseDeviceID(s string) hk.DeviceID {
+	deviceID, err := hk.ParseDeviceID(s)
+	if err != nil {
+		errorf("failed to parse device id: %v", err)
 	}
-	return l.f.Write(p)
+	return deviceID
 }
 
-func (l *lazyOpener) Close() error {
-	if l.f != nil {
-		return l.f.Close()
+// argOrStdin returns arg or, if it is empty or "-", the content read from
+// standard input.
+func argOrStdin(arg, what string) string {
+	if arg != "" && arg != "-" {
+		return arg
 	}
-	return nil
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		errorf("failed to read %s from stdin: %v", what, err)
+	}
+	return strings.TrimSuffix(string(b), "\n")
 }
 
 func warnWithHint(msg string, hints ...string) {
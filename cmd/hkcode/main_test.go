@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestMain runs hkcode instead of the tests if HKCODE_TEST_MAIN is set, so
+// tests can run the command with [runHKCode].
+func TestMain(m *testing.M) {
+	if os.Getenv("HKCODE_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runHKCode runs hkcode with the arguments and returns its combined output
+// and its error, if it failed. The registry is kept in a temporary directory.
+func runHKCode(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"HKCODE_TEST_MAIN=1",
+		"HKCODE_REGISTRY="+t.TempDir()+"/registry.jsonl",
+	)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/lukasmalkmus/hkcode/internal/manifest"
+)
+
+// manifestFlags are the flags which control how manifests are protected.
+type manifestFlags struct {
+	encryptTo string
+	signKey   string
+
+	recipient *[manifest.KeySize]byte
+	seed      *[manifest.KeySize]byte
+}
+
+func (f *manifestFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.encryptTo, "encrypt-to", "", "encrypt manifests to the public key in `FILE`")
+	fs.StringVar(&f.signKey, "sign-key", "", "sign manifests with the private key in `FILE`")
+}
+
+// load reads the keys, so missing or invalid ones are reported before any code
+// is created.
+func (f *manifestFlags) load() {
+	if f.encryptTo != "" {
+		f.recipient = readKey(f.encryptTo)
+	}
+	if f.signKey != "" {
+		f.seed = readKey(f.signKey)
+	}
+}
+
+// writeFile writes the manifest to the file at path name. Encrypted manifests
+// get the extension ".box" and are never written in plain text. Signatures
+// are written next to the manifest with the extension ".sig" and sign the
+// file as written. Manifests and signatures of earlier runs which aren't
+// replaced are removed, so no stale plain text manifest is left next to an
+// encrypted one and no stale signature next to an unsigned manifest.
+func (f *manifestFlags) writeFile(name string, data []byte) error {
+	stale := name + manifest.SealedExt
+	if f.recipient != nil {
+		var err error
+		if data, err = manifest.Seal(data, f.recipient); err != nil {
+			return err
+		}
+		name, stale = stale, name
+	}
+	for _, s := range []string{stale, stale + manifest.SignatureExt} {
+		if err := os.Remove(s); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove stale manifest: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return err
+	}
+	sigName := name + manifest.SignatureExt
+	if f.seed == nil {
+		if err := os.Remove(sigName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove stale signature: %w", err)
+		}
+		return nil
+	}
+	return os.WriteFile(sigName, manifest.Sign(data, f.seed), 0o644)
+}
+
+func manifestCmd(args []string) {
+	if len(args) == 0 {
+		errorWithHint("missing manifest command",
+			`did you forget to specify one of "verify", "decrypt" or "keygen"?`)
+	}
+
+	switch args[0] {
+	case "verify":
+		manifestVerify(args[1:])
+	case "decrypt":
+		manifestDecrypt(args[1:])
+	case "keygen":
+		manifestKeygen(args[1:])
+	default:
+		errorWithHint(fmt.Sprintf("unknown manifest command %q", args[0]),
+			`the manifest commands are "verify", "decrypt" and "keygen"`)
+	}
+}
+
+// manifestReadFlags are the flags which control how protected manifests are
+// read.
+type manifestReadFlags struct {
+	key     string
+	signPub string
+}
+
+func (f *manifestReadFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.key, "k", "", "decrypt with the private key in `FILE`")
+	fs.StringVar(&f.key, "key-file", "", "decrypt with the private key in `FILE`")
+	fs.StringVar(&f.signPub, "sign-pub", os.Getenv("HKCODE_SIGN_PUB"), "verify the signature with the public key in `FILE`")
+}
+
+// read reads the manifest at path name. Its signature is checked first and
+// must be valid if a signing public key is given or the manifest has one, so
+// a signed manifest is never read unchecked. A signature is required, too, if
+// the other manifest written along with it is signed, so removing the
+// signature doesn't make it unsigned. Encrypted manifests are decrypted. It
+// returns the contents and the name without the ".box" extension.
+func (f *manifestReadFlags) read(name string) ([]byte, string) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		errorf("failed to read manifest: %v", err)
+	}
+
+	sigName := name + manifest.SignatureExt
+	if other := otherManifest(name); other != "" && f.signPub == "" {
+		if _, err := os.Stat(other + manifest.SignatureExt); err == nil {
+			if _, err := os.Stat(sigName); errors.Is(err, os.ErrNotExist) {
+				errorf("manifest %q is signed, but signature %q is missing", other, sigName)
+			}
+		}
+	}
+	if f.signPub != "" {
+		sig, err := os.ReadFile(sigName)
+		if err != nil {
+			errorWithHint(fmt.Sprintf("failed to read manifest signature: %v", err),
+				"sign manifests with 'hkcode batch --sign-key=FILE'")
+		}
+		if err := manifest.Verify(data, sig, readKey(f.signPub)); err != nil {
+			errorf("%s: %v", name, err)
+		}
+	} else if _, err := os.Stat(sigName); err == nil {
+		errorWithHint(fmt.Sprintf("manifest is signed, but signature %q can't be checked", sigName),
+			"did you forget to specify --sign-pub?")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		errorf("failed to read manifest signature: %v", err)
+	}
+
+	if !strings.EqualFold(filepath.Ext(name), manifest.SealedExt) {
+		return data, name
+	}
+	if f.key == "" {
+		errorWithHint("manifest is encrypted",
+			"did you forget to specify -k/--key-file?")
+	}
+	if data, err = manifest.Open(data, readKey(f.key)); err != nil {
+		errorf("failed to decrypt manifest: %v", err)
+	}
+	return data, name[:len(name)-len(manifest.SealedExt)]
+}
+
+// otherManifest returns the name of the manifest written along with the
+// manifest at path name, the JSON one for the CSV one and vice versa, or ""
+// if name is neither.
+func otherManifest(name string) string {
+	dir, base := filepath.Split(name)
+	sealed := strings.EqualFold(filepath.Ext(base), manifest.SealedExt)
+	if sealed {
+		base = base[:len(base)-len(manifest.SealedExt)]
+	}
+
+	var other string
+	switch base {
+	case manifest.CSVName:
+		other = manifest.JSONName
+	case manifest.JSONName:
+		other = manifest.CSVName
+	default:
+		return ""
+	}
+	if sealed {
+		other += manifest.SealedExt
+	}
+	return filepath.Join(dir, other)
+}
+
+func manifestVerify(args []string) {
+	fs := flag.NewFlagSet("manifest verify", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var readFlags manifestReadFlags
+	readFlags.register(fs)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the manifest must be specified after all flags")
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = manifest.JSONName
+	}
+	data, plainName := readFlags.read(name)
+
+	var (
+		entries []manifest.Entry
+		err     error
+	)
+	if strings.EqualFold(filepath.Ext(plainName), ".csv") {
+		entries, err = manifest.ReadCSV(bytes.NewReader(data))
+	} else {
+		entries, err = manifest.ReadJSON(bytes.NewReader(data))
+	}
+	if err != nil {
+		errorf("failed to read manifest %q: %v", name, err)
+	}
+
+	// The files are relative to the manifest.
+	dir := filepath.Dir(name)
+	var failed int
+	for _, e := range entries {
+		if err := e.Verify(dir); err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				log.Printf("hkcode: error: %s: %s", e.File, line)
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		errorf("%d of %d files failed verification", failed, len(entries))
+	}
+	fmt.Fprintf(os.Stdout, "Verified %d files\n", len(entries))
+}
+
+func manifestDecrypt(args []string) {
+	fs := flag.NewFlagSet("manifest decrypt", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		readFlags manifestReadFlags
+		outFlag   string
+	)
+	readFlags.register(fs)
+	fs.StringVar(&outFlag, "o", "", "output to `FILE`")
+	fs.StringVar(&outFlag, "output", "", "output to `FILE`")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		errorWithHint("expected exactly one manifest",
+			"note that the manifest must be specified after all flags")
+	}
+	if readFlags.key == "" {
+		errorWithHint("missing private key file",
+			"did you forget to specify -k/--key-file?")
+	}
+	if !strings.EqualFold(filepath.Ext(fs.Arg(0)), manifest.SealedExt) {
+		errorf("manifest %q is not encrypted: must end with %q", fs.Arg(0), manifest.SealedExt)
+	}
+
+	data, _ := readFlags.read(fs.Arg(0))
+	if outFlag == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	// Decrypted manifests hold secrets, so only the owner may read them.
+	if err := os.WriteFile(outFlag, data, 0o600); err != nil {
+		errorf("failed to write output file: %v", err)
+	}
+}
+
+func manifestKeygen(args []string) {
+	fs := flag.NewFlagSet("manifest keygen", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var typeFlag, outFlag string
+	fs.StringVar(&typeFlag, "type", "", "key `TYPE`")
+	fs.StringVar(&outFlag, "o", "", "output to `FILE`")
+	fs.StringVar(&outFlag, "output", "", "output to `FILE`")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		errorf("too many arguments: %q", fs.Args())
+	}
+	if outFlag == "" {
+		errorWithHint("missing output file",
+			"did you forget to specify -o/--output?")
+	}
+
+	var pub, priv *[manifest.KeySize]byte
+	switch strings.ToLower(typeFlag) {
+	case "encrypt":
+		var err error
+		if pub, priv, err = box.GenerateKey(rand.Reader); err != nil {
+			errorf("failed to generate key: %v", err)
+		}
+	case "sign":
+		var seed [manifest.KeySize]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			errorf("failed to generate key: %v", err)
+		}
+		priv, pub = &seed, manifest.SignPublicKey(&seed)
+	default:
+		errorWithHint(fmt.Sprintf("unknown key type %q", typeFlag),
+			`--type must be one of "encrypt" or "sign"`)
+	}
+
+	// Never overwrite a private key, manifests might depend on it.
+	f, err := os.OpenFile(outFlag, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		errorf("private key file %q exists already", outFlag)
+	} else if err != nil {
+		errorf("failed to create private key file: %v", err)
+	}
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(priv[:])); err != nil {
+		errorf("failed to write private key file: %v", err)
+	} else if err := f.Close(); err != nil {
+		errorf("failed to write private key file: %v", err)
+	}
+	if err := os.WriteFile(outFlag+".pub", []byte(hex.EncodeToString(pub[:])+"\n"), 0o644); err != nil {
+		errorf("failed to write public key file: %v", err)
+	}
+	fmt.Fprintf(os.Stdout, "Private key: %s\n", outFlag)
+	fmt.Fprintf(os.Stdout, "Public key:  %s.pub\n", outFlag)
+}
+
+// readKey reads a hex encoded key from the file at path name.
+func readKey(name string) *[manifest.KeySize]byte {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		errorf("failed to read key: %v", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(key) != manifest.KeySize {
+		errorWithHint(fmt.Sprintf("invalid key file %q: must hold %d hex encoded bytes", name, manifest.KeySize),
+			"create keys with 'hkcode manifest keygen'")
+	}
+	return (*[manifest.KeySize]byte)(key)
+}
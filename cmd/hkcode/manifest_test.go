@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/lukasmalkmus/hkcode/internal/manifest"
+)
+
+func TestManifestFlags_WriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, manifest.CSVName)
+	data := []byte("serial,code\nSN1,12344321\n")
+
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var seed [manifest.KeySize]byte
+	_, err = rand.Read(seed[:])
+	require.NoError(t, err)
+
+	// A plain text manifest, signed.
+	plain := manifestFlags{seed: &seed}
+	require.NoError(t, plain.writeFile(name, data))
+	assert.FileExists(t, name)
+	assert.FileExists(t, name+manifest.SignatureExt)
+
+	// Encrypting replaces the plain text manifest and its signature.
+	sealed := manifestFlags{recipient: pub}
+	require.NoError(t, sealed.writeFile(name, data))
+	assert.NoFileExists(t, name)
+	assert.NoFileExists(t, name+manifest.SignatureExt)
+	assert.NoFileExists(t, name+manifest.SealedExt+manifest.SignatureExt)
+	b, err := os.ReadFile(name + manifest.SealedExt)
+	require.NoError(t, err)
+	got, err := manifest.Open(b, priv)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// Writing in plain text again replaces the encrypted manifest.
+	require.NoError(t, plain.writeFile(name, data))
+	assert.NoFileExists(t, name+manifest.SealedExt)
+	b, err = os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+	sig, err := os.ReadFile(name + manifest.SignatureExt)
+	require.NoError(t, err)
+	assert.NoError(t, manifest.Verify(data, sig, manifest.SignPublicKey(&seed)))
+}
+
+func TestManifestVerify_MissingSignature(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "sign.key")
+	in := filepath.Join(dir, "codes.csv")
+	out := filepath.Join(dir, "codes")
+	require.NoError(t, os.WriteFile(in, []byte("serial\nSN1\nSN2\n"), 0o644))
+
+	got, err := runHKCode(t, "manifest", "keygen", "--type=sign", "-o", key)
+	require.NoError(t, err, got)
+	got, err = runHKCode(t, "batch", "--text", "--register=false", "--sign-key="+key, "-o", out, in)
+	require.NoError(t, err, got)
+
+	jsonName := filepath.Join(out, manifest.JSONName)
+	got, err = runHKCode(t, "manifest", "verify", "--sign-pub="+key+".pub", jsonName)
+	require.NoError(t, err, got)
+
+	// The CSV manifest is still signed.
+	require.NoError(t, os.Remove(jsonName+manifest.SignatureExt))
+	got, err = runHKCode(t, "manifest", "verify", jsonName)
+	assert.Error(t, err)
+	assert.Contains(t, got, "is signed, but signature")
+
+	// A configured signing key requires a signature.
+	require.NoError(t, os.Remove(filepath.Join(out, manifest.CSVName)+manifest.SignatureExt))
+	got, err = runHKCode(t, "manifest", "verify", jsonName)
+	require.NoError(t, err, got)
+	t.Setenv("HKCODE_SIGN_PUB", key+".pub")
+	got, err = runHKCode(t, "manifest", "verify", jsonName)
+	assert.Error(t, err)
+	assert.Contains(t, got, "failed to read manifest signature")
+}
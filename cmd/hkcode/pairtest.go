@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/pairing"
+)
+
+func pairtest(args []string) {
+	fs := flag.NewFlagSet("pairtest", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		secretsFlag  string
+		deviceIDFlag string
+	)
+	fs.StringVar(&secretsFlag, "s", "", "read salt and verifier from `FILE`")
+	fs.StringVar(&secretsFlag, "secrets", "", "read salt and verifier from `FILE`")
+	fs.StringVar(&deviceIDFlag, "d", "", "device id")
+	fs.StringVar(&deviceIDFlag, "device-id", "", "device id")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the setup code must be specified after all flags")
+	}
+
+	if secretsFlag == "" {
+		errorWithHint("missing secrets file",
+			"did you forget to specify -s/--secrets?",
+			"create one with 'hkcode provision -o=FILE'")
+	}
+
+	salt, verifier := readSecrets(secretsFlag)
+	setupCode := parseSetupCode(argOrStdin(fs.Arg(0), "setup code"))
+
+	var (
+		deviceID hk.DeviceID
+		err      error
+	)
+	if deviceIDFlag != "" {
+		deviceID = parseDeviceID(deviceIDFlag)
+	} else if deviceID, err = hk.GenerateDeviceID(nil); err != nil {
+		errorf("failed to generate device id: %v", err)
+	}
+
+	res, err := pairing.Simulate(setupCode, deviceID, salt, verifier, nil)
+	if errors.Is(err, pairing.ErrorAuthentication) {
+		errorWithHint(fmt.Sprintf("pair setup failed: %v", err),
+			fmt.Sprintf("setup code %s does not match the verifier in %q", setupCode.Format(), secretsFlag))
+	} else if err != nil {
+		errorf("pair setup failed: %v", err)
+	}
+
+	fmt.Println("Pair setup succeeded (M1-M6).")
+	fmt.Printf("Accessory:   %s (%x)\n", res.Accessory.ID, res.Accessory.PublicKey)
+	fmt.Printf("Controller:  %s (%x)\n", res.Controller.ID, res.Controller.PublicKey)
+}
+
+// readSecrets reads the salt and verifier from a file written by the provision
+// command.
+func readSecrets(name string) (salt, verifier []byte) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		errorf("failed to read secrets file: %v", err)
+	}
+
+	var f provisionFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		errorf("failed to decode secrets file %q: %v", name, err)
+	}
+
+	if salt, err = hex.DecodeString(f.Salt); err != nil || len(salt) == 0 {
+		errorf("failed to decode salt from secrets file %q: %v", name, err)
+	}
+	if verifier, err = hex.DecodeString(f.Verifier); err != nil || len(verifier) == 0 {
+		errorf("failed to decode verifier from secrets file %q: %v", name, err)
+	}
+
+	return salt, verifier
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lukasmalkmus/hkcode/hk/srp"
+)
+
+// provisionFile is the JSON representation of the accessory secrets written by
+// the provision command.
+type provisionFile struct {
+	Salt     string `json:"salt"`
+	Verifier string `json:"verifier"`
+}
+
+func provision(args []string) {
+	fs := flag.NewFlagSet("provision", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		outFlag     string
+		policyFlags policyFlags
+	)
+	fs.StringVar(&outFlag, "o", "", "output to `FILE`")
+	fs.StringVar(&outFlag, "output", "", "output to `FILE`")
+	policyFlags.register(fs)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the setup code must be specified after all flags")
+	}
+
+	setupCode := parseSetupCode(argOrStdin(fs.Arg(0), "setup code"))
+	if err := policyFlags.policy().Check(setupCode); err != nil {
+		errorWithPolicyHint("failed to provision setup code", err)
+	}
+
+	salt, verifier, err := srp.NewVerifier(setupCode, nil)
+	if err != nil {
+		errorf("failed to create verifier: %v", err)
+	}
+
+	if outFlag == "" {
+		fmt.Printf("Salt:      %x\n", salt)
+		fmt.Printf("Verifier:  %x\n", verifier)
+		return
+	}
+
+	b, err := json.MarshalIndent(provisionFile{
+		Salt:     hex.EncodeToString(salt),
+		Verifier: hex.EncodeToString(verifier),
+	}, "", "  ")
+	if err != nil {
+		errorf("failed to encode secrets: %v", err)
+	}
+
+	// The verifier is a secret, so the file is only readable by its owner.
+	if err := os.WriteFile(outFlag, append(b, '\n'), 0o600); err != nil {
+		errorf("failed to write output file %q: %v", outFlag, err)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/internal/registry"
+)
+
+// maxIssueAttempts is the number of times setup codes and setup ids are
+// generated again when they are registered already.
+const maxIssueAttempts = 100
+
+// registryFlags are the flags which control the registry setup codes are
+// issued through.
+type registryFlags struct {
+	path     string
+	enabled  bool
+	operator string
+}
+
+// register registers the registry flags. The flags for issuing setup codes
+// are only registered if issue is true.
+func (f *registryFlags) register(fs *flag.FlagSet, issue bool) {
+	fs.StringVar(&f.path, "registry", defaultRegistryPath(), "registry at `PATH`")
+	if issue {
+		fs.BoolVar(&f.enabled, "register", true, "issue setup codes through the registry")
+		fs.StringVar(&f.operator, "operator", defaultOperator(), "operator `NAME`")
+	}
+}
+
+// open opens the registry for issuing setup codes, or returns nil if it is not
+// used.
+func (f *registryFlags) open() *registry.Registry {
+	if !f.enabled {
+		return nil
+	}
+	reg, err := registry.Open(f.path)
+	if err != nil {
+		errorWithHint(fmt.Sprintf("failed to open registry: %v", err),
+			"use --registry=PATH to use another one or --register=false to skip it")
+	}
+	return reg
+}
+
+// load returns the records of the registry.
+func (f *registryFlags) load() []registry.Record {
+	records, err := registry.Load(f.path)
+	if err != nil {
+		errorf("failed to read registry: %v", err)
+	}
+	return records
+}
+
+// record returns the registry record of the setup information issued now.
+func (f *registryFlags) record(info hk.SetupInfo) registry.Record {
+	return registry.Record{
+		Info:     info,
+		Time:     time.Now().UTC(),
+		Operator: f.operator,
+	}
+}
+
+// defaultRegistryPath returns $HKCODE_REGISTRY or, if not set, registry.jsonl
+// in the hkcode directory of the user's config directory.
+func defaultRegistryPath() string {
+	if path := os.Getenv("HKCODE_REGISTRY"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "registry.jsonl"
+	}
+	return filepath.Join(dir, "hkcode", "registry.jsonl")
+}
+
+// defaultOperator returns $HKCODE_OPERATOR or, if not set, the name of the
+// current user.
+func defaultOperator() string {
+	if name := os.Getenv("HKCODE_OPERATOR"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// reserve reserves the setup information in the registry. Generated setup
+// codes and setup ids which are registered already are generated again, setup
+// codes complying with the policy.
+func reserve(reg *registry.Registry, info *hk.SetupInfo, policy hk.Policy, genCode, genID bool) error {
+	for attempt := 1; ; attempt++ {
+		err := reg.Reserve(*info)
+		if err == nil {
+			return nil
+		}
+
+		dupCode := errors.Is(err, registry.ErrDuplicateCode)
+		dupID := errors.Is(err, registry.ErrDuplicateID)
+		if attempt == maxIssueAttempts || errors.Is(err, registry.ErrDuplicateSerial) ||
+			(dupCode && !genCode) || (dupID && !genID) {
+			return err
+		}
+
+		if dupCode {
+			if info.Code, err = policy.GenerateCode(nil); err != nil {
+				return fmt.Errorf("generate setup code: %w", err)
+			}
+		}
+		if dupID {
+			if info.ID, err = hk.GenerateID(nil); err != nil {
+				return fmt.Errorf("generate setup id: %w", err)
+			}
+		}
+	}
+}
+
+func registryCmd(args []string) {
+	if len(args) == 0 {
+		errorWithHint("missing registry command",
+			`did you forget to specify one of "list", "search" or "reprint"?`)
+	}
+
+	switch args[0] {
+	case "list":
+		registryList(args[1:])
+	case "search":
+		registrySearch(args[1:])
+	case "reprint":
+		registryReprint(args[1:])
+	default:
+		errorWithHint(fmt.Sprintf("unknown registry command %q", args[0]),
+			`the registry commands are "list", "search" and "reprint"`)
+	}
+}
+
+func registryList(args []string) {
+	fs := flag.NewFlagSet("registry list", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var registryFlags registryFlags
+	registryFlags.register(fs, false)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		errorf("too many arguments: %q", fs.Args())
+	}
+
+	printRecords(registryFlags.load())
+}
+
+func registrySearch(args []string) {
+	fs := flag.NewFlagSet("registry search", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var registryFlags registryFlags
+	registryFlags.register(fs, false)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		errorWithHint("expected exactly one query",
+			"note that the query must be specified after all flags")
+	}
+
+	var found []registry.Record
+	for _, rec := range registryFlags.load() {
+		if rec.Match(fs.Arg(0)) {
+			found = append(found, rec)
+		}
+	}
+	if len(found) == 0 {
+		errorf("no setup codes match %q", fs.Arg(0))
+	}
+	printRecords(found)
+}
+
+func registryReprint(args []string) {
+	fs := flag.NewFlagSet("registry reprint", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		createFlags   createFlags
+		registryFlags registryFlags
+	)
+	createFlags.register(fs)
+	registryFlags.register(fs, false)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		errorWithHint("expected exactly one serial number, setup code or setup id",
+			"note that it must be specified after all flags")
+	}
+	if !createFlags.text && !createFlags.qr {
+		errorWithHint("missing mode",
+			"did you forget to specify one of -t/--text or -q/--qr?")
+	}
+	if createFlags.setupID != "" || createFlags.setupFlags.Flag != hk.FlagNone || createFlags.category.Category > 0 {
+		errorf("-i/--id, -f/--flag and -c/--category can't be used with reprint")
+	}
+	createFlags.validate()
+
+	// Unlike search, the key must match exactly, so only one record is
+	// reprinted. Setup ids are case-insensitive, like in the registry.
+	key := fs.Arg(0)
+	code, codeErr := hk.ParseCode(key)
+	var found []registry.Record
+	for _, rec := range registryFlags.load() {
+		if rec.Info.SerialNumber == key || (rec.Info.ID != "" && strings.EqualFold(rec.Info.ID.String(), key)) ||
+			(codeErr == nil && rec.Info.Code == code) {
+			found = append(found, rec)
+		}
+	}
+	switch len(found) {
+	case 0:
+		errorWithHint(fmt.Sprintf("%q is not registered", key),
+			"use 'hkcode registry search' to look it up")
+	case 1:
+	default:
+		errorWithHint(fmt.Sprintf("%q matches %d registered setup codes", key, len(found)),
+			"use the serial number, setup code or setup id of a single one")
+	}
+
+	// The registered setup code is reprinted as issued, even if it doesn't
+	// comply with the current policy.
+	createFlags.strict, createFlags.weak = false, false
+	createFlags.createInfo(found[0].Info)
+}
+
+// printRecords prints the records as a table.
+func printRecords(records []registry.Record) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSERIAL\tCODE\tID\tCATEGORY\tOPERATOR")
+	for _, rec := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", rec.Time.Local().Format(time.RFC3339),
+			orDash(rec.Info.SerialNumber), rec.Info.Code.Format(), orDash(rec.Info.ID.String()),
+			rec.Info.Category.Name(), orDash(rec.Operator))
+	}
+	_ = tw.Flush()
+}
+
+// orDash returns s or, if it is empty, "-".
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
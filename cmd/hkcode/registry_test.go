@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryReprint(t *testing.T) {
+	dir := t.TempDir()
+	reg := "--registry=" + filepath.Join(dir, "registry.jsonl")
+
+	out, err := runHKCode(t, "generate", reg, "-i", "RFGD", "--serial", "SN1", "12344321")
+	require.NoError(t, err, out)
+
+	// Setup ids match regardless of case, like in search.
+	for _, key := range []string{"SN1", "12344321", "RFGD", "rfgd"} {
+		name := filepath.Join(dir, key+".png")
+		out, err := runHKCode(t, "registry", "reprint", reg, "--qr", "-o", name, key)
+		require.NoError(t, err, out)
+		assert.FileExists(t, name)
+	}
+
+	out, err = runHKCode(t, "registry", "reprint", reg, "--qr", "-o", filepath.Join(dir, "x.png"), "sn1")
+	assert.Error(t, err)
+	assert.Contains(t, out, `"sn1" is not registered`)
+}
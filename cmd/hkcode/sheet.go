@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+// layouts are the label sheet layouts selectable by name.
+var layouts = map[string]qr.Layout{
+	"avery-l7651": qr.LayoutAveryL7651,
+	"avery-5160":  qr.LayoutAvery5160,
+	"square-30mm": qr.LayoutSquare30,
+}
+
+// pageSizes are the page sizes selectable by name, in millimetres.
+var pageSizes = map[string][2]float64{
+	"a4":     {210, 297},
+	"a5":     {148, 210},
+	"letter": {215.9, 279.4},
+}
+
+func sheet(args []string) {
+	fs := flag.NewFlagSet("sheet", flag.ExitOnError)
+	fs.Usage = flag.Usage
+
+	var (
+		outFlag       string
+		layoutFlag    string
+		pageSizeFlag  string
+		marginsFlag   string
+		labelSizeFlag string
+		pitchFlag     string
+		gridFlag      string
+		paddingFlag   float64
+		cropMarksFlag bool
+		bleedFlag     float64
+		boxFlag       bool
+		captionFlag   bool
+		fontFlag      fontFlag
+		policyFlags   policyFlags
+	)
+	fs.StringVar(&outFlag, "o", "", "output to `FILE`")
+	fs.StringVar(&outFlag, "output", "", "output to `FILE`")
+	fs.StringVar(&layoutFlag, "layout", "avery-l7651", "label sheet `LAYOUT`")
+	fs.StringVar(&pageSizeFlag, "page-size", "", "page `SIZE`")
+	fs.StringVar(&marginsFlag, "margins", "", "page `MARGINS`")
+	fs.StringVar(&labelSizeFlag, "label-size", "", "label `SIZE`")
+	fs.StringVar(&pitchFlag, "pitch", "", "label `PITCH`")
+	fs.StringVar(&gridFlag, "grid", "", "label `GRID`")
+	fs.Float64Var(&paddingFlag, "padding", -1, "label padding in `MM`")
+	fs.BoolVar(&cropMarksFlag, "crop-marks", false, "print crop marks")
+	fs.Float64Var(&bleedFlag, "bleed", 0, "bleed in `MM`")
+	fs.BoolVar(&boxFlag, "b", false, "create boxed qr codes")
+	fs.BoolVar(&boxFlag, "box", false, "create boxed qr codes")
+	fs.BoolVar(&captionFlag, "caption", true, "print serial numbers")
+	fs.Var(&fontFlag, "font", "`FONT` name or file")
+	policyFlags.register(fs)
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		errorWithHint(fmt.Sprintf("too many arguments: %q", fs.Args()),
+			"note that the input file must be specified after all flags")
+	}
+	if outFlag == "" {
+		errorWithHint("missing output file",
+			"did you forget to specify -o/--output?")
+	}
+
+	layout, ok := layouts[strings.ToLower(layoutFlag)]
+	if !ok {
+		errorWithHint(fmt.Sprintf("unknown layout %q", layoutFlag),
+			fmt.Sprintf("--layout must be one of %s", quotedKeys(layouts)))
+	}
+	if pageSizeFlag != "" {
+		size, ok := pageSizes[strings.ToLower(pageSizeFlag)]
+		if !ok {
+			var err error
+			if size[0], size[1], err = parsePair(pageSizeFlag, "x", false); err != nil {
+				errorWithHint(fmt.Sprintf("invalid --page-size %q: %v", pageSizeFlag, err),
+					fmt.Sprintf("--page-size must be one of %s or WIDTHxHEIGHT in millimetres", quotedKeys(pageSizes)))
+			}
+		}
+		layout.PageWidth, layout.PageHeight = size[0], size[1]
+	}
+	setPair := func(flag, value, sep string, allowZero bool, a, b *float64) {
+		if value == "" {
+			return
+		}
+		var err error
+		if *a, *b, err = parsePair(value, sep, allowZero); err != nil {
+			errorf("invalid %s %q: %v", flag, value, err)
+		}
+	}
+	setPair("--margins", marginsFlag, ",", true, &layout.MarginTop, &layout.MarginLeft)
+	setPair("--label-size", labelSizeFlag, "x", false, &layout.LabelWidth, &layout.LabelHeight)
+	setPair("--pitch", pitchFlag, "x", false, &layout.PitchX, &layout.PitchY)
+	if gridFlag != "" {
+		columns, rows, err := parsePair(gridFlag, "x", false)
+		if err != nil || columns != float64(int(columns)) || rows != float64(int(rows)) {
+			errorWithHint(fmt.Sprintf("invalid --grid %q", gridFlag),
+				"--grid must be COLUMNSxROWS, e.g. 5x13")
+		}
+		layout.Columns, layout.Rows = int(columns), int(rows)
+	}
+	if paddingFlag >= 0 {
+		layout.Padding = paddingFlag
+	}
+	if err := layout.Validate(); err != nil {
+		errorf("invalid layout: %s", strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	in := os.Stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			errorf("failed to open input file: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	infos, err := readSetupInfos(in)
+	if err != nil {
+		errorf("failed to read setup information: %v", err)
+	}
+	if len(infos) == 0 {
+		errorWithHint("no setup information given",
+			"pass a file with one setup information per line or pipe it via stdin")
+	}
+
+	s := qr.Sheet{
+		Layout:    layout,
+		Boxed:     boxFlag,
+		Caption:   captionFlag,
+		CropMarks: cropMarksFlag,
+		Bleed:     bleedFlag,
+	}
+	// A bleed only makes sense with a background to extend.
+	if bleedFlag > 0 {
+		s.Background = color.White
+	}
+
+	out := newLazyOpener(outFlag)
+	defer func() {
+		if err := out.Close(); err != nil {
+			errorf("failed to close output file %q: %v", outFlag, err)
+		}
+	}()
+
+	opts := []qr.Option{qr.WithPolicy(policyFlags.policy())}
+	if fontFlag.Provider != nil {
+		opts = append(opts, qr.WithFont(fontFlag))
+	}
+	if err := qr.WriteSheet(out, infos, s, opts...); err != nil {
+		errorWithPolicyHint("failed to create sheet", err)
+	}
+}
+
+// readSetupInfos reads one setup information per line, either as a JSON object
+// or in the query string form of [hk.SetupInfo.MarshalText]. Empty lines and
+// lines starting with "#" are skipped.
+func readSetupInfos(r io.Reader) ([]hk.SetupInfo, error) {
+	var infos []hk.SetupInfo
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var (
+			info hk.SetupInfo
+			err  error
+		)
+		if strings.HasPrefix(text, "{") {
+			err = json.Unmarshal([]byte(text), &info)
+		} else {
+			err = info.UnmarshalText([]byte(text))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, sc.Err()
+}
+
+// parsePair parses two positive numbers separated by sep, like "210x297". If
+// allowZero is true, zero is accepted as well. Infinite numbers and NaN are
+// rejected.
+func parsePair(s, sep string, allowZero bool) (float64, float64, error) {
+	a, b, ok := strings.Cut(s, sep)
+	if !ok {
+		return 0, 0, fmt.Errorf("want two numbers separated by %q", sep)
+	}
+	x, err := parseDimension(a, allowZero)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := parseDimension(b, allowZero)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+// parseDimension parses a finite, positive number or, if allowZero is true,
+// zero.
+func parseDimension(s string, allowZero bool) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	switch {
+	case err != nil:
+		return 0, err
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		return 0, fmt.Errorf("%s is not a finite number", strings.TrimSpace(s))
+	case v < 0:
+		return 0, fmt.Errorf("%s is negative", strings.TrimSpace(s))
+	case v == 0 && !allowZero:
+		return 0, fmt.Errorf("%s is not positive", strings.TrimSpace(s))
+	}
+	return v, nil
+}
+
+// quotedKeys returns the sorted keys of m, quoted and separated by commas.
+func quotedKeys[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, strconv.Quote(k))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
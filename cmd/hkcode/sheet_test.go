@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePair(t *testing.T) {
+	x, y, err := parsePair("210x297", "x", false)
+	require.NoError(t, err)
+	assert.Equal(t, 210.0, x)
+	assert.Equal(t, 297.0, y)
+
+	x, y, err = parsePair(" 0, 4.5 ", ",", true)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, x)
+	assert.Equal(t, 4.5, y)
+
+	for _, s := range []string{
+		"210",
+		"ax297",
+		"0x297",
+		"-210x297",
+		"NaNx297",
+		"210xInf",
+		"210x-Inf",
+	} {
+		_, _, err := parsePair(s, "x", false)
+		assert.Error(t, err, s)
+	}
+	for _, s := range []string{"-1,0", "0,NaN", "+Inf,0"} {
+		_, _, err := parsePair(s, ",", true)
+		assert.Error(t, err, s)
+	}
+}
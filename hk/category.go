@@ -1,7 +1,16 @@
 package hk
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 //go:generate go run golang.org/x/tools/cmd/stringer -type=Category -linecomment -output=category_string.go
 
+// ErrInvalidCategory can be returned when a [Category] can't be parsed.
+var ErrInvalidCategory = fmt.Errorf("invalid category")
+
 // Category represents an Apple HomeKit® accessory category.
 type Category uint8
 
@@ -23,7 +32,7 @@ const (
 	CategoryWindow                             // Window
 	CategoryWindowCovering                     // Window Covering
 	CategoryProgrammableSwitch                 // Programmable Switch
-	_                                          // Range Extender (?)
+	CategoryRangeExtender                      // Range Extender
 	CategoryIPCamera                           // IP Camera
 	CategoryVideoDoorbell                      // Video Doorbell
 	CategoryAirPurifier                        // Air Purifier
@@ -31,13 +40,80 @@ const (
 	CategoryAirConditioner                     // Air Conditioner
 	CategoryHumidifier                         // Humidifier
 	CategoryDehumidifier                       // Dehumidifier
-	_                                          // Apple TV (?)
-	_                                          // <reserved>
-	_                                          // Speaker (?)
-	_                                          // Airport (?)
+	CategoryAppleTV                            // Apple TV
+	CategoryHomePod                            // HomePod
+	CategorySpeaker                            // Speaker
+	CategoryAirPort                            // AirPort
 	CategorySprinklers                         // Sprinklers
 	CategoryFaucets                            // Faucets
 	CategoryShowerSystems                      // Shower Systems
-	_                                          // Television (?)
-	_                                          // Remote Control (?)
+	CategoryTelevision                         // Television
+	CategoryRemoteControl                      // Remote Control
+	CategoryRouter                             // Router
+	CategoryAudioReceiver                      // Audio Receiver
+	CategoryTVSetTopBox                        // TV Set Top Box
+	CategoryTVStreamingStick                   // TV Streaming Stick
 )
+
+// maxCategory is the highest known category.
+const maxCategory = CategoryTVStreamingStick
+
+// ParseCategory parses a category from its display name ("Garage Door
+// Opener"), its name in snake or kebab case ("garage_door_opener",
+// "garage-door-opener") or its number ("4"). Names are matched
+// case-insensitively. Numbers of categories unknown to this package are
+// accepted as well, as long as they fit into a byte.
+func ParseCategory(s string) (Category, error) {
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Category(n), nil
+	}
+
+	name := normalizeCategoryName(s)
+	for c := CategoryUnknown; c <= maxCategory; c++ {
+		if normalizeCategoryName(c.String()) == name {
+			return c, nil
+		}
+	}
+	return CategoryUnknown, fmt.Errorf("%w: %q", ErrInvalidCategory, s)
+}
+
+// Name returns the name of the category in snake case, e.g.
+// "garage_door_opener". For categories unknown to this package, the number is
+// returned instead.
+func (c Category) Name() string {
+	if c > maxCategory {
+		return strconv.Itoa(int(c))
+	}
+	return strings.ReplaceAll(strings.ToLower(c.String()), " ", "_")
+}
+
+// MarshalText returns the [Category.Name] of the category.
+//
+// Implements [encoding.TextMarshaler].
+func (c Category) MarshalText() ([]byte, error) {
+	return []byte(c.Name()), nil
+}
+
+// UnmarshalText parses the category using [ParseCategory].
+//
+// Implements [encoding.TextUnmarshaler].
+func (c *Category) UnmarshalText(text []byte) error {
+	category, err := ParseCategory(string(text))
+	if err != nil {
+		return err
+	}
+	*c = category
+	return nil
+}
+
+// normalizeCategoryName lowercases s and removes all spaces, underscores and
+// hyphens.
+func normalizeCategoryName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+}
@@ -24,6 +24,7 @@ func _() {
 	_ = x[CategoryWindow-13]
 	_ = x[CategoryWindowCovering-14]
 	_ = x[CategoryProgrammableSwitch-15]
+	_ = x[CategoryRangeExtender-16]
 	_ = x[CategoryIPCamera-17]
 	_ = x[CategoryVideoDoorbell-18]
 	_ = x[CategoryAirPurifier-19]
@@ -31,34 +32,28 @@ func _() {
 	_ = x[CategoryAirConditioner-21]
 	_ = x[CategoryHumidifier-22]
 	_ = x[CategoryDehumidifier-23]
+	_ = x[CategoryAppleTV-24]
+	_ = x[CategoryHomePod-25]
+	_ = x[CategorySpeaker-26]
+	_ = x[CategoryAirPort-27]
 	_ = x[CategorySprinklers-28]
 	_ = x[CategoryFaucets-29]
 	_ = x[CategoryShowerSystems-30]
+	_ = x[CategoryTelevision-31]
+	_ = x[CategoryRemoteControl-32]
+	_ = x[CategoryRouter-33]
+	_ = x[CategoryAudioReceiver-34]
+	_ = x[CategoryTVSetTopBox-35]
+	_ = x[CategoryTVStreamingStick-36]
 }
 
-const (
-	_Category_name_0 = "UnknownOtherBridgeFanGarage Door OpenerLightbulbDoor LockOutletSwitchThermostatSensorSecurity SystemDoorWindowWindow CoveringProgrammable Switch"
-	_Category_name_1 = "IP CameraVideo DoorbellAir PurifierHeaterAir ConditionerHumidifierDehumidifier"
-	_Category_name_2 = "SprinklersFaucetsShower Systems"
-)
+const _Category_name = "UnknownOtherBridgeFanGarage Door OpenerLightbulbDoor LockOutletSwitchThermostatSensorSecurity SystemDoorWindowWindow CoveringProgrammable SwitchRange ExtenderIP CameraVideo DoorbellAir PurifierHeaterAir ConditionerHumidifierDehumidifierApple TVHomePodSpeakerAirPortSprinklersFaucetsShower SystemsTelevisionRemote ControlRouterAudio ReceiverTV Set Top BoxTV Streaming Stick"
 
-var (
-	_Category_index_0 = [...]uint8{0, 7, 12, 18, 21, 39, 48, 57, 63, 69, 79, 85, 100, 104, 110, 125, 144}
-	_Category_index_1 = [...]uint8{0, 9, 23, 35, 41, 56, 66, 78}
-	_Category_index_2 = [...]uint8{0, 10, 17, 31}
-)
+var _Category_index = [...]uint16{0, 7, 12, 18, 21, 39, 48, 57, 63, 69, 79, 85, 100, 104, 110, 125, 144, 158, 167, 181, 193, 199, 214, 224, 236, 244, 251, 258, 265, 275, 282, 296, 306, 320, 326, 340, 354, 372}
 
 func (i Category) String() string {
-	switch {
-	case i <= 15:
-		return _Category_name_0[_Category_index_0[i]:_Category_index_0[i+1]]
-	case 17 <= i && i <= 23:
-		i -= 17
-		return _Category_name_1[_Category_index_1[i]:_Category_index_1[i+1]]
-	case 28 <= i && i <= 30:
-		i -= 28
-		return _Category_name_2[_Category_index_2[i]:_Category_index_2[i+1]]
-	default:
+	if i >= Category(len(_Category_index)-1) {
 		return "Category(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
+	return _Category_name[_Category_index[i]:_Category_index[i+1]]
 }
@@ -0,0 +1,72 @@
+package hk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategory_String(t *testing.T) {
+	assert.Equal(t, "Range Extender", CategoryRangeExtender.String())
+	assert.Equal(t, "TV Streaming Stick", CategoryTVStreamingStick.String())
+	assert.Equal(t, "Category(37)", Category(37).String())
+}
+
+func TestParseCategory(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Category
+	}{
+		{"Garage Door Opener", CategoryGarageDoorOpener},
+		{"garage_door_opener", CategoryGarageDoorOpener},
+		{"garage-door-opener", CategoryGarageDoorOpener},
+		{"GARAGEDOOROPENER", CategoryGarageDoorOpener},
+		{" switch ", CategorySwitch},
+		{"ip_camera", CategoryIPCamera},
+		{"apple_tv", CategoryAppleTV},
+		{"homepod", CategoryHomePod},
+		{"tv_set_top_box", CategoryTVSetTopBox},
+		{"unknown", CategoryUnknown},
+		{"0", CategoryUnknown},
+		{"8", CategorySwitch},
+		{"36", CategoryTVStreamingStick},
+		{"200", Category(200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCategory(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, input := range []string{"", "toaster", "256", "-1", "switch!"} {
+		_, err := ParseCategory(input)
+		assert.ErrorIs(t, err, ErrInvalidCategory, input)
+	}
+}
+
+func TestCategory_MarshalText(t *testing.T) {
+	for c := CategoryUnknown; c <= maxCategory; c++ {
+		text, err := c.MarshalText()
+		require.NoError(t, err)
+
+		var got Category
+		require.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, c, got, string(text))
+	}
+
+	text, err := Category(200).MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "200", string(text))
+
+	b, err := json.Marshal(map[string]Category{"category": CategoryGarageDoorOpener})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"category":"garage_door_opener"}`, string(b))
+
+	var got struct{ Category Category }
+	require.NoError(t, json.Unmarshal([]byte(`{"Category":"Window Covering"}`), &got))
+	assert.Equal(t, CategoryWindowCovering, got.Category)
+}
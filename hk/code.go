@@ -1,8 +1,13 @@
 package hk
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 // ErrInvalidCode can be returned when the [Code] is not valid.
@@ -27,14 +32,110 @@ func (c Code) String() string {
 	return s
 }
 
+// CodeStyle is a layout a [Code] can be formatted in, see [Code.FormatAs].
+type CodeStyle uint8
+
+// All available code styles.
+const (
+	CodeStyleDashed  CodeStyle = iota // XXX-XX-XXX, the Apple preferred format
+	CodeStyleGrouped                  // XXXX XXXX, as printed on boxed codes
+	CodeStylePlain                    // XXXXXXXX
+)
+
+// ParseCode parses a setup code in one of the common human readable layouts:
+//
+//   - XXX-XX-XXX as returned by [Code.Format]
+//   - XXXX XXXX as printed on the two rows of a boxed QR code
+//   - up to 8 bare digits, optionally without the leading zeros
+//
+// Leading and trailing whitespace, including line breaks, is ignored. Groups
+// can be separated by dashes or whitespace but both must not be mixed. Grouped
+// codes must have all 8 digits. Other input is rejected with an error
+// describing the problem, wrapping [ErrInvalidCode].
+func ParseCode(s string) (Code, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidCode)
+	}
+
+	var (
+		digits    []byte
+		groups    []int
+		separator rune
+		inGroup   bool
+	)
+	for i, r := range s {
+		switch {
+		case '0' <= r && r <= '9':
+			if !inGroup {
+				groups = append(groups, 0)
+				inGroup = true
+			}
+			groups[len(groups)-1]++
+			digits = append(digits, byte(r))
+		case r == '-' || unicode.IsSpace(r):
+			kind := '-'
+			if unicode.IsSpace(r) {
+				kind = ' '
+			}
+			if separator == 0 {
+				separator = kind
+			} else if separator != kind {
+				return 0, fmt.Errorf("%w: %q mixes dashes and whitespace as separators", ErrInvalidCode, s)
+			}
+			if !inGroup && (kind == '-' || i == 0) {
+				return 0, fmt.Errorf("%w: %q has an empty group at position %d", ErrInvalidCode, s, i)
+			}
+			inGroup = false
+		default:
+			return 0, fmt.Errorf("%w: %q has an invalid character %q at position %d", ErrInvalidCode, s, r, i)
+		}
+	}
+	if !inGroup {
+		return 0, fmt.Errorf("%w: %q ends with a separator", ErrInvalidCode, s)
+	}
+
+	switch {
+	case len(groups) == 1 && len(digits) > 8:
+		return 0, fmt.Errorf("%w: %q has %d digits, want at most 8", ErrInvalidCode, s, len(digits))
+	case len(groups) == 1:
+	case slicesEqual(groups, []int{3, 2, 3}), slicesEqual(groups, []int{4, 4}):
+	default:
+		layout := make([]string, len(groups))
+		for i, n := range groups {
+			layout[i] = strings.Repeat("X", n)
+		}
+		return 0, fmt.Errorf("%w: %q has the layout %s, want XXX-XX-XXX or XXXX XXXX",
+			ErrInvalidCode, s, strings.Join(layout, string(separator)))
+	}
+
+	n, _ := strconv.ParseUint(string(digits), 10, 32)
+	return Code(n), nil
+}
+
 // Format returns the code in the Apple preferred format XXX-XX-XXX. If the code
 // is not valid, it returns an empty string.
 func (c Code) Format() string {
+	return c.FormatAs(CodeStyleDashed)
+}
+
+// FormatAs returns the code in the given style. If the code or the style is
+// not valid, it returns an empty string. All styles can be parsed by
+// [ParseCode].
+func (c Code) FormatAs(style CodeStyle) string {
 	if !c.Valid() {
 		return ""
 	}
 	s := c.String()
-	return fmt.Sprintf("%s-%s-%s", s[0:3], s[3:5], s[5:8])
+	switch style {
+	case CodeStyleDashed:
+		return fmt.Sprintf("%s-%s-%s", s[0:3], s[3:5], s[5:8])
+	case CodeStyleGrouped:
+		return fmt.Sprintf("%s %s", s[0:4], s[4:8])
+	case CodeStylePlain:
+		return s
+	}
+	return ""
 }
 
 // Valid returns true if the code is valid, false otherwise. Valid codes are
@@ -42,3 +143,77 @@ func (c Code) Format() string {
 func (c Code) Valid() bool {
 	return c <= 99999999
 }
+
+// MarshalText returns the code as 8 digits, see [Code.String]. It fails if the
+// code is not valid.
+//
+// Implements [encoding.TextMarshaler].
+func (c Code) MarshalText() ([]byte, error) {
+	if !c.Valid() {
+		return nil, fmt.Errorf("%w: %d is out of range", ErrInvalidCode, uint32(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses the code using [ParseCode].
+//
+// Implements [encoding.TextUnmarshaler].
+func (c *Code) UnmarshalText(text []byte) error {
+	code, err := ParseCode(string(text))
+	if err != nil {
+		return err
+	}
+	*c = code
+	return nil
+}
+
+// Trivial returns true if the code is a trivial one. Trivial codes are
+// forbidden by the HomeKit Accessory Protocol Specification. These are codes
+// consisting of a single repeated digit (00000000, 11111111, ..., 99999999),
+// 12345678 and 87654321.
+func (c Code) Trivial() bool {
+	switch c {
+	case 12345678, 87654321:
+		return true
+	}
+	return c.Valid() && c%11111111 == 0
+}
+
+// GenerateCode generates a random, non-trivial setup code. Codes are drawn
+// uniformly from the random source r which should be cryptographically secure.
+// If r is nil, [crypto/rand.Reader] is used.
+func GenerateCode(r io.Reader) (Code, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	// Discard values from the top of the range which would make some codes
+	// more likely than others.
+	const limit = (1 << 32) / 100000000 * 100000000
+
+	var buf [4]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, fmt.Errorf("read random bytes: %w", err)
+		}
+		v := binary.BigEndian.Uint32(buf[:])
+		if v >= limit {
+			continue
+		}
+		if c := Code(v % 100000000); !c.Trivial() {
+			return c, nil
+		}
+	}
+}
+
+func slicesEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,9 +1,11 @@
 package hk_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lukasmalkmus/hkcode/hk"
 )
@@ -53,3 +55,102 @@ func TestCode(t *testing.T) {
 		})
 	}
 }
+
+func TestCode_FormatAs(t *testing.T) {
+	code := hk.Code(1234567)
+
+	assert.Equal(t, "012-34-567", code.FormatAs(hk.CodeStyleDashed))
+	assert.Equal(t, "0123 4567", code.FormatAs(hk.CodeStyleGrouped))
+	assert.Equal(t, "01234567", code.FormatAs(hk.CodeStylePlain))
+	assert.Empty(t, code.FormatAs(hk.CodeStyle(42)))
+	assert.Empty(t, hk.Code(100000000).FormatAs(hk.CodeStylePlain))
+
+	for _, style := range []hk.CodeStyle{hk.CodeStyleDashed, hk.CodeStyleGrouped, hk.CodeStylePlain} {
+		got, err := hk.ParseCode(code.FormatAs(style))
+		require.NoError(t, err)
+		assert.Equal(t, code, got)
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  hk.Code
+	}{
+		{"12344321", 12344321},
+		{"1234", 1234},
+		{"00001234", 1234},
+		{"0", 0},
+		{"123-44-321", 12344321},
+		{"012-34-567", 1234567},
+		{"1234 4321", 12344321},
+		{"1234\t4321", 12344321},
+		{"1234   4321", 12344321},
+		{"1234-4321", 12344321},
+		{"123 44 321", 12344321},
+		{"  123-44-321\r\n", 12344321},
+		{"12344321\n", 12344321},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := hk.ParseCode(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCode_Error(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"", `invalid setup code: empty`},
+		{" \r\n", `invalid setup code: empty`},
+		{"123456789", `invalid setup code: "123456789" has 9 digits, want at most 8`},
+		{"123-45-67", `invalid setup code: "123-45-67" has the layout XXX-XX-XX, want XXX-XX-XXX or XXXX XXXX`},
+		{"12 34 56 78", `invalid setup code: "12 34 56 78" has the layout XX XX XX XX, want XXX-XX-XXX or XXXX XXXX`},
+		{"123-45 678", `invalid setup code: "123-45 678" mixes dashes and whitespace as separators`},
+		{"123--45-678", `invalid setup code: "123--45-678" has an empty group at position 4`},
+		{"-12345678", `invalid setup code: "-12345678" has an empty group at position 0`},
+		{"1234-", `invalid setup code: "1234-" ends with a separator`},
+		{"123-45-67a", `invalid setup code: "123-45-67a" has an invalid character 'a' at position 9`},
+		{"+1234", `invalid setup code: "+1234" has an invalid character '+' at position 0`},
+		{"１２３４", `invalid setup code: "１２３４" has an invalid character '１' at position 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := hk.ParseCode(tt.input)
+			require.ErrorIs(t, err, hk.ErrInvalidCode)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGenerateCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := hk.GenerateCode(nil)
+		require.NoError(t, err)
+
+		assert.True(t, code.Valid())
+		assert.False(t, code.Trivial())
+	}
+}
+
+func TestGenerateCode_Deterministic(t *testing.T) {
+	// The first value is discarded as it exceeds the range, the second one
+	// is trivial.
+	r := bytes.NewReader([]byte{
+		0xff, 0xff, 0xff, 0xff,
+		0x00, 0xbc, 0x61, 0x4e,
+		0x00, 0xbc, 0x61, 0x4f,
+	})
+
+	code, err := hk.GenerateCode(r)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 12345679, code)
+
+	_, err = hk.GenerateCode(r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package hk
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveVersion is the version of the derivation scheme used by
+// [DeriveSetupInfo]. Older versions stay available through
+// [DeriveSetupInfoVersion], so setup information derived before a rotation can
+// be recreated.
+const DeriveVersion = 1
+
+// MinMasterKeySize is the minimum size of a master key in bytes.
+const MinMasterKeySize = 16
+
+// deriveSalt is the HKDF salt of all derivations. It separates them from other
+// uses of the master key.
+const deriveSalt = "hkcode setup info derivation"
+
+// DeriveSetupInfo derives the setup code and setup id of the accessory with
+// the given serial number from a master key, using the current version of the
+// derivation scheme. The same master key and serial number always result in
+// the same setup information, so it can be recreated from the serial number
+// instead of being stored. The master key must be kept secret and should be at
+// least 32 random bytes. Flags and category are left empty.
+func DeriveSetupInfo(masterKey []byte, serial string) (SetupInfo, error) {
+	return DeriveSetupInfoVersion(masterKey, serial, DeriveVersion)
+}
+
+// DeriveSetupInfoVersion is like [DeriveSetupInfo] but uses the given version
+// of the derivation scheme.
+//
+// Version 1 expands the master key with HKDF-SHA256. The info parameter is
+// "hkcode/v1/code" or "hkcode/v1/id", a zero byte and the serial number. The
+// setup code and setup id are taken from the output as described by
+// [deriveCodeV1] and [deriveIDV1]. Version 1 is frozen: it doesn't depend on
+// [GenerateCode], [GenerateID] or [Code.Trivial], so changing them doesn't
+// change the setup information derived.
+func DeriveSetupInfoVersion(masterKey []byte, serial string, version int) (SetupInfo, error) {
+	if version != 1 {
+		return SetupInfo{}, fmt.Errorf("unsupported derivation version %d", version)
+	} else if len(masterKey) < MinMasterKeySize {
+		return SetupInfo{}, fmt.Errorf("master key has %d bytes, need at least %d", len(masterKey), MinMasterKeySize)
+	} else if serial == "" {
+		return SetupInfo{}, fmt.Errorf("serial number is empty")
+	} else if len(serial) > maxStringLength {
+		return SetupInfo{}, fmt.Errorf("serial number exceeds %d bytes", maxStringLength)
+	}
+
+	// Each value is drawn from its own stream, so changing how one of them is
+	// derived doesn't change the other.
+	stream := func(purpose string) io.Reader {
+		info := fmt.Sprintf("hkcode/v%d/%s\x00%s", version, purpose, serial)
+		return hkdf.New(sha256.New, masterKey, []byte(deriveSalt), []byte(info))
+	}
+
+	code, err := deriveCodeV1(stream("code"))
+	if err != nil {
+		return SetupInfo{}, fmt.Errorf("derive setup code: %w", err)
+	}
+	id, err := deriveIDV1(stream("id"))
+	if err != nil {
+		return SetupInfo{}, fmt.Errorf("derive setup id: %w", err)
+	}
+
+	return SetupInfo{
+		Code:         code,
+		ID:           id,
+		SerialNumber: serial,
+	}, nil
+}
+
+// deriveCodeV1 takes the setup code of version 1 from r. It reads 4 bytes at
+// a time as big-endian unsigned integer v. Values of v of 4200000000 or more
+// are skipped, so all codes are equally likely. Else the code is v modulo
+// 100000000, unless it is 12345678, 87654321 or consists of a single repeated
+// digit, in which case it is skipped as well.
+func deriveCodeV1(r io.Reader) (Code, error) {
+	const limit = 4200000000
+
+	var buf [4]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		v := binary.BigEndian.Uint32(buf[:])
+		if v >= limit {
+			continue
+		}
+		switch c := v % 100000000; {
+		case c == 12345678, c == 87654321, c%11111111 == 0:
+			continue
+		default:
+			return Code(c), nil
+		}
+	}
+}
+
+// deriveIDV1 takes the setup id of version 1 from r. It reads 4 bytes at a
+// time and takes a character from each byte b in order, until it has 4. Bytes
+// of 252 or more are skipped, so all characters are equally likely. Else the
+// character is the one at index b modulo 36 of the digits followed by the
+// uppercase letters A to Z. The remaining bytes of the last 4 are unused.
+func deriveIDV1(r io.Reader) (ID, error) {
+	const (
+		alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+		limit    = 252
+	)
+
+	var (
+		id  = make([]byte, 0, 4)
+		buf [4]byte
+	)
+	for len(id) < cap(id) {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(id) < cap(id) {
+				id = append(id, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return ID(id), nil
+}
@@ -0,0 +1,90 @@
+package hk_test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// testMasterKey is the master key of the test vectors, the bytes 0x00 to 0x1f.
+var testMasterKey = func() []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}()
+
+func TestDeriveSetupInfo(t *testing.T) {
+	// These test vectors must never change, else setup codes derived before
+	// can't be recreated anymore. Introduce a new version instead.
+	tests := []struct {
+		masterKey []byte
+		serial    string
+		wantCode  hk.Code
+		wantID    hk.ID
+	}{
+		{testMasterKey, "SN1", 34158388, "87XJ"},
+		{testMasterKey, "SN2", 11168805, "21RV"},
+		{testMasterKey, "HK-2024-000001", 36382018, "FX9S"},
+		{testMasterKey, "ünïcödé", 92594128, "C662"},
+		// The first 4 bytes of the code stream are above the rejection
+		// limit.
+		{testMasterKey, "SN25", 15585475, "RFN0"},
+		// The first byte of the id stream is above the rejection limit.
+		{testMasterKey, "SN3", 26477589, "RA7D"},
+		{[]byte("0123456789abcdef"), "SN1", 8880141, "R582"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.serial, func(t *testing.T) {
+			info, err := hk.DeriveSetupInfo(tt.masterKey, tt.serial)
+			require.NoError(t, err)
+
+			assert.Equal(t, hk.SetupInfo{
+				Code:         tt.wantCode,
+				ID:           tt.wantID,
+				SerialNumber: tt.serial,
+			}, info)
+			assert.NoError(t, info.Validate())
+			assert.False(t, info.Code.Trivial())
+
+			same, err := hk.DeriveSetupInfoVersion(tt.masterKey, tt.serial, 1)
+			require.NoError(t, err)
+			assert.Equal(t, info, same)
+		})
+	}
+}
+
+func TestDeriveSetupInfo_Scheme(t *testing.T) {
+	// The first 4 bytes of the code stream are a code below the rejection
+	// limit for this vector.
+	r := hkdf.New(sha256.New, testMasterKey, []byte("hkcode setup info derivation"), []byte("hkcode/v1/code\x00SN1"))
+	var buf [4]byte
+	_, err := io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+
+	info, err := hk.DeriveSetupInfo(testMasterKey, "SN1")
+	require.NoError(t, err)
+	assert.EqualValues(t, binary.BigEndian.Uint32(buf[:])%100000000, info.Code)
+}
+
+func TestDeriveSetupInfo_Error(t *testing.T) {
+	_, err := hk.DeriveSetupInfo(testMasterKey[:15], "SN1")
+	assert.EqualError(t, err, "master key has 15 bytes, need at least 16")
+
+	_, err = hk.DeriveSetupInfo(testMasterKey, "")
+	assert.EqualError(t, err, "serial number is empty")
+
+	_, err = hk.DeriveSetupInfo(testMasterKey, string(make([]byte, 65)))
+	assert.EqualError(t, err, "serial number exceeds 64 bytes")
+
+	_, err = hk.DeriveSetupInfoVersion(testMasterKey, "SN1", 2)
+	assert.EqualError(t, err, "unsupported derivation version 2")
+}
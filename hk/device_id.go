@@ -0,0 +1,95 @@
+package hk
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidDeviceID can be returned when the [DeviceID] is not valid.
+var ErrInvalidDeviceID = fmt.Errorf("invalid device id")
+
+// DeviceID represents an Apple HomeKit® accessory identifier. It is a 48-bit
+// value formatted like a MAC address (XX:XX:XX:XX:XX:XX) and advertised via
+// Bonjour as "id". It is not necessarily related to any hardware address.
+type DeviceID [6]byte
+
+// ParseDeviceID parses a device id of the form XX:XX:XX:XX:XX:XX where X is a
+// hexadecimal digit. Lowercase digits are accepted.
+func ParseDeviceID(s string) (DeviceID, error) {
+	var id DeviceID
+
+	parts := strings.Split(s, ":")
+	if len(parts) != len(id) {
+		return DeviceID{}, fmt.Errorf("%w: %q is not of the form XX:XX:XX:XX:XX:XX", ErrInvalidDeviceID, s)
+	}
+	for i, part := range parts {
+		if len(part) != 2 {
+			return DeviceID{}, fmt.Errorf("%w: %q is not of the form XX:XX:XX:XX:XX:XX", ErrInvalidDeviceID, s)
+		} else if _, err := hex.Decode(id[i:i+1], []byte(part)); err != nil {
+			return DeviceID{}, fmt.Errorf("%w: %q: %v", ErrInvalidDeviceID, s, err)
+		}
+	}
+
+	return id, nil
+}
+
+// GenerateDeviceID generates a random device id using the random source r. If r
+// is nil, [crypto/rand.Reader] is used.
+func GenerateDeviceID(r io.Reader) (DeviceID, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	var id DeviceID
+	if _, err := io.ReadFull(r, id[:]); err != nil {
+		return DeviceID{}, fmt.Errorf("read random bytes: %w", err)
+	}
+	return id, nil
+}
+
+// String returns the device id in the form XX:XX:XX:XX:XX:XX with uppercase
+// hexadecimal digits.
+//
+// Implements [fmt.Stringer].
+func (id DeviceID) String() string {
+	return fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X", id[0], id[1], id[2], id[3], id[4], id[5])
+}
+
+// MarshalText returns the device id in the form XX:XX:XX:XX:XX:XX, see
+// [DeviceID.String].
+//
+// Implements [encoding.TextMarshaler].
+func (id DeviceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText parses the device id using [ParseDeviceID].
+//
+// Implements [encoding.TextUnmarshaler].
+func (id *DeviceID) UnmarshalText(text []byte) error {
+	deviceID, err := ParseDeviceID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = deviceID
+	return nil
+}
+
+// IsZero returns true if the device id is the zero value.
+func (id DeviceID) IsZero() bool {
+	return id == DeviceID{}
+}
+
+// SetupHash returns the setup hash of an accessory, advertised via Bonjour as
+// "sh". It ties a setup payload to the accessory that can be discovered on the
+// network. It is the Base64 encoding of the first 4 bytes of the SHA-512 hash
+// of the setup id concatenated with the device id.
+func SetupHash(id ID, deviceID DeviceID) string {
+	sum := sha512.Sum512([]byte(id.String() + deviceID.String()))
+	return base64.StdEncoding.EncodeToString(sum[:4])
+}
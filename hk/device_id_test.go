@@ -0,0 +1,81 @@
+package hk_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func TestParseDeviceID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    hk.DeviceID
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			input: "AC:1F:74:0B:5E:91",
+			want:  hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91},
+		},
+		{
+			name:  "valid - lowercase",
+			input: "ac:1f:74:0b:5e:91",
+			want:  hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91},
+		},
+		{
+			name:    "invalid - too short",
+			input:   "AC:1F:74:0B:5E",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - wrong separator",
+			input:   "AC-1F-74-0B-5E-91",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - single digit",
+			input:   "AC:1F:74:B:5E:91",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - not hexadecimal",
+			input:   "AC:1F:74:0G:5E:91",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hk.ParseDeviceID(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, hk.ErrInvalidDeviceID)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "AC:1F:74:0B:5E:91", got.String())
+		})
+	}
+}
+
+func TestGenerateDeviceID(t *testing.T) {
+	id, err := hk.GenerateDeviceID(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "01:02:03:04:05:06", id.String())
+	assert.False(t, id.IsZero())
+	assert.True(t, hk.DeviceID{}.IsZero())
+}
+
+func TestSetupHash(t *testing.T) {
+	deviceID, err := hk.ParseDeviceID("ac:1f:74:0b:5e:91")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Y4Pa8A==", hk.SetupHash("7osx", deviceID))
+	assert.Equal(t, "G6s5ig==", hk.SetupHash("RFGD", hk.DeviceID{}))
+}
@@ -2,9 +2,14 @@ package hk
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
+// ErrInvalidFlag can be returned when a [Flag] can't be parsed or has unknown
+// bits set.
+var ErrInvalidFlag = fmt.Errorf("invalid setup flag")
+
 // Flag represents an Apple HomeKit® setup flag. Setup flags indicate the
 // supported pairing methods. However, Apple devices seem to ignore them and it
 // doesn't matter which flags are set, if even.
@@ -19,6 +24,40 @@ const (
 	maxFlag
 )
 
+// knownFlags are all bits of the known setup flags.
+const knownFlags = FlagNFC | FlagIP | FlagBTLE
+
+// ParseFlag parses setup flags from a list of flag names ("nfc", "ip" and
+// "btle") separated by "|" or ",", e.g. "ip|btle" or "nfc,ip". Names are matched
+// case-insensitively. "none" and the empty string are parsed as [FlagNone]. A
+// number is parsed as the raw bits which might include unknown flags, see
+// [Flag.Valid].
+func ParseFlag(s string) (Flag, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Flag(n), nil
+	}
+
+	var f Flag
+	if s == "" {
+		return f, nil
+	}
+	for _, name := range strings.Split(strings.ReplaceAll(s, ",", "|"), "|") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "nfc":
+			f |= FlagNFC
+		case "ip":
+			f |= FlagIP
+		case "btle":
+			f |= FlagBTLE
+		case "none", "<none>":
+		default:
+			return FlagNone, fmt.Errorf("%w: unknown flag %q", ErrInvalidFlag, name)
+		}
+	}
+	return f, nil
+}
+
 // String returns a string representation of the flag.
 //
 // It implements [fmt.Stringer].
@@ -44,3 +83,34 @@ func (f Flag) String() string {
 	}
 	return strings.Join(res, "|")
 }
+
+// Valid reports whether only bits of known setup flags are set.
+func (f Flag) Valid() bool {
+	return f&^knownFlags == 0
+}
+
+// MarshalText returns the lowercased flag names separated by "|", e.g.
+// "ip|btle", or "none" for [FlagNone]. If unknown bits are set, the raw bits
+// are returned as decimal number instead.
+//
+// Implements [encoding.TextMarshaler].
+func (f Flag) MarshalText() ([]byte, error) {
+	if !f.Valid() {
+		return []byte(strconv.Itoa(int(f))), nil
+	} else if f == FlagNone {
+		return []byte("none"), nil
+	}
+	return []byte(strings.ToLower(f.String())), nil
+}
+
+// UnmarshalText parses the flag using [ParseFlag].
+//
+// Implements [encoding.TextUnmarshaler].
+func (f *Flag) UnmarshalText(text []byte) error {
+	flag, err := ParseFlag(string(text))
+	if err != nil {
+		return err
+	}
+	*f = flag
+	return nil
+}
@@ -1,9 +1,11 @@
 package hk
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFlag_String(t *testing.T) {
@@ -15,3 +17,64 @@ func TestFlag_String(t *testing.T) {
 	typ |= FlagBTLE
 	assert.Equal(t, "IP|BTLE", typ.String())
 }
+
+func TestParseFlag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Flag
+	}{
+		{"", FlagNone},
+		{"none", FlagNone},
+		{"nfc", FlagNFC},
+		{"IP", FlagIP},
+		{"ip|btle", FlagIP | FlagBTLE},
+		{"nfc, ip", FlagNFC | FlagIP},
+		{"btle|ip,nfc", FlagNFC | FlagIP | FlagBTLE},
+		{"ip|ip", FlagIP},
+		{"12", FlagIP | FlagBTLE},
+		{"16", Flag(16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFlag(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, input := range []string{"wifi", "ip|", "ip;btle", "256"} {
+		_, err := ParseFlag(input)
+		assert.ErrorIs(t, err, ErrInvalidFlag, input)
+	}
+}
+
+func TestFlag_Valid(t *testing.T) {
+	assert.True(t, FlagNone.Valid())
+	assert.True(t, (FlagNFC | FlagIP | FlagBTLE).Valid())
+	assert.False(t, Flag(1).Valid())
+	assert.False(t, (FlagIP | 16).Valid())
+}
+
+func TestFlag_MarshalText(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		f := Flag(i)
+		text, err := f.MarshalText()
+		require.NoError(t, err)
+
+		var got Flag
+		require.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, f, got, string(text))
+	}
+
+	text, err := Flag(17).MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "17", string(text))
+
+	b, err := json.Marshal(map[string]Flag{"flags": FlagIP | FlagBTLE})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"flags":"ip|btle"}`, string(b))
+
+	var got struct{ Flags Flag }
+	require.NoError(t, json.Unmarshal([]byte(`{"Flags":"nfc,ip"}`), &got))
+	assert.Equal(t, FlagNFC|FlagIP, got.Flags)
+}
@@ -0,0 +1,9 @@
+// Package font provides the fonts setup codes are set in.
+//
+// Renderers take a [Provider], so callers can use their own TrueType or
+// OpenType fonts, loaded with [Load], [LoadFS] or [Parse]. The fonts used by
+// Apple, [SFMonoBold] and [Scancardium], are only available on Apple platforms
+// as their licenses do not allow redistribution elsewhere. The freely licensed
+// Go fonts, [GoMono] and [GoMonoBold], are available everywhere and are used as
+// fallback, see [Fallback].
+package font
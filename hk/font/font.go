@@ -0,0 +1,142 @@
+package font
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/sfnt"
+
+	embeddedFont "github.com/lukasmalkmus/hkcode/internal/assets/font"
+)
+
+// ErrUnavailable is returned by the providers of built-in fonts which are not
+// available on this platform.
+var ErrUnavailable = embeddedFont.ErrUnavailable
+
+// A Provider provides a font. Fonts must be safe for concurrent use which is
+// the case for [sfnt.Font] as long as each call uses its own [sfnt.Buffer].
+type Provider interface {
+	// Font returns the font or an error if it is not available.
+	Font() (*sfnt.Font, error)
+}
+
+// ProviderFunc is an adapter to use a function as a [Provider].
+type ProviderFunc func() (*sfnt.Font, error)
+
+// Font calls f.
+//
+// Implements [Provider].
+func (f ProviderFunc) Font() (*sfnt.Font, error) {
+	return f()
+}
+
+// Built-in fonts.
+var (
+	// SFMonoBold is San Francisco Mono Bold (SF Mono Bold), the font of the
+	// digits on boxed QR codes. Only available on Apple platforms.
+	SFMonoBold Provider = ProviderFunc(embeddedFont.SFMonoBold)
+	// Scancardium is Scancardium 2.0, the font of text codes. Only available on
+	// Apple platforms.
+	Scancardium Provider = ProviderFunc(embeddedFont.Scancardium)
+	// GoMono is Go Mono, a fixed-width font of the Go font family.
+	GoMono Provider = builtin(gomono.TTF, "Go Mono")
+	// GoMonoBold is Go Mono Bold, a fixed-width font of the Go font family.
+	GoMonoBold Provider = builtin(gomonobold.TTF, "Go Mono Bold")
+)
+
+var builtins = map[string]Provider{
+	"sf-mono-bold": SFMonoBold,
+	"scancardium":  Scancardium,
+	"go-mono":      GoMono,
+	"go-mono-bold": GoMonoBold,
+}
+
+// Lookup returns the built-in font with the given name, one of those returned
+// by [Names].
+func Lookup(name string) (Provider, bool) {
+	p, ok := builtins[name]
+	return p, ok
+}
+
+// Names returns the sorted names of all built-in fonts, including the ones not
+// available on this platform.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtin returns a provider which parses the font data and checks its name.
+func builtin(data []byte, name string) Provider {
+	return ProviderFunc(func() (*sfnt.Font, error) {
+		f, err := sfnt.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse font: %w", err)
+		} else if fontName, _ := f.Name(nil, sfnt.NameIDFull); fontName != name {
+			return nil, fmt.Errorf("unexpected font name %q", fontName)
+		}
+		return f, nil
+	})
+}
+
+// Fallback returns a provider which returns the font of the first provider
+// that has it available. Only [ErrUnavailable] causes a fallback to the next
+// provider, other errors are returned as they are.
+func Fallback(providers ...Provider) Provider {
+	return ProviderFunc(func() (*sfnt.Font, error) {
+		for _, p := range providers {
+			f, err := p.Font()
+			if errors.Is(err, ErrUnavailable) {
+				continue
+			}
+			return f, err
+		}
+		return nil, ErrUnavailable
+	})
+}
+
+// Parse parses a TrueType or OpenType font and returns a provider for it.
+func Parse(data []byte) (Provider, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+	return static{f}, nil
+}
+
+// Load reads and parses the TrueType or OpenType font file at path, see
+// [Parse].
+func Load(path string) (Provider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read font file: %w", err)
+	}
+	return Parse(data)
+}
+
+// LoadFS reads and parses the TrueType or OpenType font file with the given
+// name from fsys, see [Parse].
+func LoadFS(fsys fs.FS, name string) (Provider, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read font file: %w", err)
+	}
+	return Parse(data)
+}
+
+// static provides an already parsed font.
+type static struct {
+	f *sfnt.Font
+}
+
+func (s static) Font() (*sfnt.Font, error) {
+	return s.f, nil
+}
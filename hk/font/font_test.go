@@ -0,0 +1,85 @@
+package font_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/lukasmalkmus/hkcode/hk/font"
+)
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"go-mono", "go-mono-bold", "scancardium", "sf-mono-bold"}, font.Names())
+
+	for _, name := range font.Names() {
+		p, ok := font.Lookup(name)
+		require.True(t, ok, name)
+
+		if _, err := p.Font(); !errors.Is(err, font.ErrUnavailable) {
+			assert.NoError(t, err, name)
+		}
+	}
+
+	_, ok := font.Lookup("comic-sans")
+	assert.False(t, ok)
+}
+
+func TestFallback(t *testing.T) {
+	unavailable := font.ProviderFunc(func() (*sfnt.Font, error) { return nil, font.ErrUnavailable })
+	broken := font.ProviderFunc(func() (*sfnt.Font, error) { return nil, errors.New("broken") })
+
+	f, err := font.Fallback(unavailable, font.GoMono, broken).Font()
+	require.NoError(t, err)
+	assertFontName(t, "Go Mono", f)
+
+	_, err = font.Fallback(unavailable, broken, font.GoMono).Font()
+	assert.EqualError(t, err, "broken")
+
+	_, err = font.Fallback(unavailable).Font()
+	assert.ErrorIs(t, err, font.ErrUnavailable)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "font.ttf")
+	require.NoError(t, os.WriteFile(path, gomono.TTF, 0o600))
+
+	p, err := font.Load(path)
+	require.NoError(t, err)
+	f, err := p.Font()
+	require.NoError(t, err)
+	assertFontName(t, "Go Mono", f)
+
+	_, err = font.Load(filepath.Join(t.TempDir(), "missing.ttf"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fonts/font.ttf": {Data: gomono.TTF},
+		"fonts/bad.ttf":  {Data: []byte("not a font")},
+	}
+
+	p, err := font.LoadFS(fsys, "fonts/font.ttf")
+	require.NoError(t, err)
+	f, err := p.Font()
+	require.NoError(t, err)
+	assertFontName(t, "Go Mono", f)
+
+	_, err = font.LoadFS(fsys, "fonts/bad.ttf")
+	assert.ErrorContains(t, err, "parse font: ")
+}
+
+func assertFontName(t *testing.T, want string, f *sfnt.Font) {
+	t.Helper()
+
+	name, err := f.Name(nil, sfnt.NameIDFull)
+	require.NoError(t, err)
+	assert.Equal(t, want, name)
+}
@@ -1,16 +1,31 @@
 package hk
 
 import (
+	"crypto/rand"
 	"fmt"
+	"io"
 	"strings"
 )
 
 // ErrInvalidID can be returned when the [ID] is not valid.
 var ErrInvalidID = fmt.Errorf("invalid setup id")
 
+// idAlphabet are the characters a setup id is made of.
+const idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
 // ID represents an Apple HomeKit® setup id.
 type ID string
 
+// ParseID parses a setup id. Lowercase characters are accepted and normalized
+// to their uppercase counterparts.
+func ParseID(s string) (ID, error) {
+	id := ID(s)
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	return ID(strings.ToUpper(s)), nil
+}
+
 // String returns a string representation of the code. All characters are
 // uppercased.
 //
@@ -19,8 +34,81 @@ func (id ID) String() string {
 	return strings.ToUpper(string(id))
 }
 
-// Valid returns true if the id is valid, false otherwise. Valid ids have 4
-// digits.
+// Valid returns true if the id is valid, false otherwise. Valid ids consist of
+// 4 characters from the base36 alphabet [0-9A-Z]. Lowercase characters are
+// accepted as well.
 func (id ID) Valid() bool {
-	return len(id) == 4
+	return id.Validate() == nil
+}
+
+// Validate returns an error if the id is not valid. Invalid characters are
+// reported as [*InvalidIDCharError]. All errors wrap [ErrInvalidID].
+func (id ID) Validate() error {
+	var n int
+	for pos, r := range string(id) {
+		if !isIDChar(r) {
+			return &InvalidIDCharError{Char: r, Pos: pos}
+		}
+		n++
+	}
+	if n != 4 {
+		return fmt.Errorf("%w: must be 4 characters long, got %d", ErrInvalidID, n)
+	}
+	return nil
+}
+
+// isIDChar returns true if r is part of the base36 alphabet, ignoring case.
+// Only ASCII characters are accepted as some non-ASCII ones map to ASCII ones
+// when uppercased.
+func isIDChar(r rune) bool {
+	return ('0' <= r && r <= '9') || ('A' <= r && r <= 'Z') || ('a' <= r && r <= 'z')
+}
+
+// InvalidIDCharError is returned when an [ID] contains a character which is not
+// part of the base36 alphabet [0-9A-Z].
+type InvalidIDCharError struct {
+	// Char is the invalid character.
+	Char rune
+	// Pos is the byte offset of the invalid character.
+	Pos int
+}
+
+// Error implements [error].
+func (e *InvalidIDCharError) Error() string {
+	return fmt.Sprintf("%s: invalid character %q at position %d", ErrInvalidID, e.Char, e.Pos)
+}
+
+// Unwrap returns [ErrInvalidID].
+func (e *InvalidIDCharError) Unwrap() error {
+	return ErrInvalidID
+}
+
+// GenerateID generates a random setup id. The characters are drawn uniformly
+// from the random source r which should be cryptographically secure. If r is
+// nil, [crypto/rand.Reader] is used.
+func GenerateID(r io.Reader) (ID, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	// Discard values from the top of the range which would make some
+	// characters more likely than others.
+	const limit = 256 / len(idAlphabet) * len(idAlphabet)
+
+	var (
+		id  = make([]byte, 0, 4)
+		buf [4]byte
+	)
+	for len(id) < cap(id) {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return "", fmt.Errorf("read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(id) < cap(id) {
+				id = append(id, idAlphabet[int(b)%len(idAlphabet)])
+			}
+		}
+	}
+
+	return ID(id), nil
 }
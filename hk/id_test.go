@@ -1,9 +1,11 @@
 package hk_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lukasmalkmus/hkcode/hk"
 )
@@ -45,6 +47,24 @@ func TestID(t *testing.T) {
 			wantString: "ABCDE",
 			wantValid:  false,
 		},
+		{
+			name:       "invalid - special characters",
+			id:         "ab-!",
+			wantString: "AB-!",
+			wantValid:  false,
+		},
+		{
+			name:       "invalid - multi-byte character",
+			id:         "abé",
+			wantString: "ABÉ",
+			wantValid:  false,
+		},
+		{
+			name:       "invalid - non-ASCII letter uppercasing to ASCII",
+			id:         "ıabc",
+			wantString: "IABC",
+			wantValid:  false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,3 +73,45 @@ func TestID(t *testing.T) {
 		})
 	}
 }
+
+func TestParseID(t *testing.T) {
+	id, err := hk.ParseID("a1b2")
+	require.NoError(t, err)
+	assert.EqualValues(t, "A1B2", id)
+
+	_, err = hk.ParseID("a1b")
+	assert.ErrorIs(t, err, hk.ErrInvalidID)
+
+	_, err = hk.ParseID("a1-2")
+	assert.ErrorIs(t, err, hk.ErrInvalidID)
+	assert.EqualError(t, err, `invalid setup id: invalid character '-' at position 2`)
+
+	var charErr *hk.InvalidIDCharError
+	if assert.ErrorAs(t, err, &charErr) {
+		assert.Equal(t, '-', charErr.Char)
+		assert.Equal(t, 2, charErr.Pos)
+	}
+}
+
+func TestGenerateID(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		id, err := hk.GenerateID(nil)
+		require.NoError(t, err)
+
+		assert.True(t, id.Valid())
+		assert.Equal(t, id.String(), string(id))
+	}
+}
+
+func TestGenerateID_Deterministic(t *testing.T) {
+	// Bytes above 251 are discarded.
+	r := bytes.NewReader([]byte{0, 10, 252, 35, 255, 36, 0, 0})
+
+	id, err := hk.GenerateID(r)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, "0AZ0", id)
+
+	_, err = hk.GenerateID(r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package pairing
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/srp"
+)
+
+// Accessory is the accessory side of the pair setup procedure. It only knows
+// the SRP salt and verifier, not the setup code.
+type Accessory struct {
+	id         string
+	privateKey ed25519.PrivateKey
+	srp        *srp.Server
+
+	state      byte
+	controller Peer
+}
+
+// NewAccessory creates an accessory with the given device id, SRP salt and
+// verifier as created by [srp.NewVerifier]. Its long-term key and SRP private
+// key are read from the random source r which should be cryptographically
+// secure. If r is nil, [crypto/rand.Reader] is used.
+func NewAccessory(deviceID hk.DeviceID, salt, verifier []byte, r io.Reader) (*Accessory, error) {
+	r = randReader(r)
+
+	_, privateKey, err := ed25519.GenerateKey(r)
+	if err != nil {
+		return nil, fmt.Errorf("create long-term key: %w", err)
+	}
+
+	server, err := srp.NewServer(salt, verifier, r)
+	if err != nil {
+		return nil, fmt.Errorf("create SRP server: %w", err)
+	}
+
+	return &Accessory{
+		id:         deviceID.String(),
+		privateKey: privateKey,
+		srp:        server,
+	}, nil
+}
+
+// ID returns the accessory's pairing identifier, its device id.
+func (a *Accessory) ID() string {
+	return a.id
+}
+
+// PublicKey returns the accessory's long-term public key.
+func (a *Accessory) PublicKey() ed25519.PublicKey {
+	return a.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Handle handles the M1, M3 and M5 messages sent by the controller and returns
+// the M2, M4 and M6 messages to send in response. If the controller fails to
+// authenticate, the response carries an [ErrorAuthentication] error item and
+// the error is returned as well.
+func (a *Accessory) Handle(msg []byte) ([]byte, error) {
+	m, err := decodeMessage(msg, a.state+1)
+	if err != nil {
+		return nil, err
+	}
+	a.state++
+
+	switch a.state {
+	case 1:
+		if m.Method == nil || *m.Method != methodPairSetup {
+			return nil, fmt.Errorf("%w: unsupported method", ErrUnexpectedMessage)
+		}
+
+		a.state = 2
+		return encode(message{
+			State:     2,
+			PublicKey: a.srp.PublicKey(),
+			Salt:      a.srp.Salt(),
+		}), nil
+	case 3:
+		if err := a.srp.VerifyProof(m.PublicKey, m.Proof); err != nil {
+			return a.reject(4), fmt.Errorf("verify controller proof: %w", err)
+		}
+
+		a.state = 4
+		return encode(message{State: 4, Proof: a.srp.Proof()}), nil
+	case 5:
+		peer, err := openSubMessage(a.srp.SessionKey(), controllerSignSalt, controllerSignInfo,
+			controllerNonceText, m.EncryptedData)
+		if errors.Is(err, ErrorAuthentication) {
+			return a.reject(6), err
+		} else if err != nil {
+			return nil, err
+		}
+
+		encryptedData, err := newSubMessage(a.srp.SessionKey(), accessorySignSalt, accessorySignInfo,
+			accessoryNonceText, a.id, a.privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		a.state = 6
+		a.controller = peer
+		return encode(message{State: 6, EncryptedData: encryptedData}), nil
+	}
+
+	return nil, fmt.Errorf("%w: state M%d", ErrUnexpectedMessage, a.state)
+}
+
+// Done returns true if the pair setup procedure has completed successfully.
+func (a *Accessory) Done() bool {
+	return a.state == 6
+}
+
+// Controller returns the controller paired with. It is only valid after the
+// pair setup procedure has completed successfully.
+func (a *Accessory) Controller() Peer {
+	return a.controller
+}
+
+// reject returns an authentication error response for the given state.
+func (a *Accessory) reject(state byte) []byte {
+	code := ErrorAuthentication
+	return encode(message{State: state, Error: &code})
+}
@@ -0,0 +1,148 @@
+package pairing
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/srp"
+)
+
+// Controller is the controller side of the pair setup procedure, e.g. an
+// iPhone. It knows the setup code.
+type Controller struct {
+	id         string
+	privateKey ed25519.PrivateKey
+	srp        *srp.Client
+
+	state     byte
+	accessory Peer
+}
+
+// NewController creates a controller for the given setup code. Its pairing
+// identifier, long-term key and SRP private key are read from the random source
+// r which should be cryptographically secure. If r is nil,
+// [crypto/rand.Reader] is used.
+func NewController(code hk.Code, r io.Reader) (*Controller, error) {
+	r = randReader(r)
+
+	id, err := newUUID(r)
+	if err != nil {
+		return nil, fmt.Errorf("create pairing identifier: %w", err)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(r)
+	if err != nil {
+		return nil, fmt.Errorf("create long-term key: %w", err)
+	}
+
+	client, err := srp.NewClient(code, r)
+	if err != nil {
+		return nil, fmt.Errorf("create SRP client: %w", err)
+	}
+
+	return &Controller{
+		id:         id,
+		privateKey: privateKey,
+		srp:        client,
+	}, nil
+}
+
+// ID returns the controller's pairing identifier.
+func (c *Controller) ID() string {
+	return c.id
+}
+
+// PublicKey returns the controller's long-term public key.
+func (c *Controller) PublicKey() ed25519.PublicKey {
+	return c.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Start returns the M1 message which starts the pair setup procedure.
+func (c *Controller) Start() []byte {
+	c.state = 1
+	method := uint8(methodPairSetup)
+	return encode(message{State: 1, Method: &method})
+}
+
+// Handle handles the M2, M4 and M6 messages sent by the accessory and returns
+// the M3 and M5 messages to send in response. After M6 has been handled
+// successfully, no message is returned and [Controller.Done] reports true.
+// Errors reported by the accessory are returned as [ErrorCode].
+func (c *Controller) Handle(msg []byte) ([]byte, error) {
+	m, err := decodeMessage(msg, c.state+1)
+	if err != nil {
+		return nil, err
+	}
+	c.state++
+
+	if m.Error != nil {
+		return nil, fmt.Errorf("accessory reported: %w", *m.Error)
+	}
+
+	switch c.state {
+	case 2:
+		if len(m.Salt) == 0 || len(m.PublicKey) == 0 {
+			return nil, fmt.Errorf("%w: missing salt or public key", ErrUnexpectedMessage)
+		}
+
+		proof, err := c.srp.Proof(m.Salt, m.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		c.state = 3
+		return encode(message{
+			State:     3,
+			PublicKey: c.srp.PublicKey(),
+			Proof:     proof,
+		}), nil
+	case 4:
+		if err := c.srp.VerifyProof(m.Proof); err != nil {
+			return nil, fmt.Errorf("verify accessory proof: %w", err)
+		}
+
+		encryptedData, err := newSubMessage(c.srp.SessionKey(), controllerSignSalt, controllerSignInfo,
+			controllerNonceText, c.id, c.privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		c.state = 5
+		return encode(message{State: 5, EncryptedData: encryptedData}), nil
+	case 6:
+		peer, err := openSubMessage(c.srp.SessionKey(), accessorySignSalt, accessorySignInfo,
+			accessoryNonceText, m.EncryptedData)
+		if err != nil {
+			return nil, err
+		}
+		c.accessory = peer
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: state M%d", ErrUnexpectedMessage, c.state)
+}
+
+// Done returns true if the pair setup procedure has completed successfully.
+func (c *Controller) Done() bool {
+	return c.state == 6 && c.accessory.ID != ""
+}
+
+// Accessory returns the accessory paired with. It is only valid after the pair
+// setup procedure has completed successfully.
+func (c *Controller) Accessory() Peer {
+	return c.accessory
+}
+
+// newUUID returns a random (version 4) UUID in its canonical, uppercased
+// string form, as used for controller pairing identifiers.
+func newUUID(r io.Reader) (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,10 @@
+// Package pairing implements an offline simulation of the Apple HomeKit®
+// pair setup procedure (M1-M6). It proves that a controller knowing the setup
+// code printed on a label can pair with an accessory which only knows the SRP
+// salt and verifier created from it.
+//
+// Both halves, [Controller] and [Accessory], exchange TLV8 encoded messages
+// just like they would over the network. The exchange uses SRP-6a for the
+// setup code, HKDF-SHA-512 for key derivation, ChaCha20-Poly1305 for the
+// encrypted sub-messages and Ed25519 for the long-term keys.
+package pairing
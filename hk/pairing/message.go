@@ -0,0 +1,48 @@
+package pairing
+
+import (
+	"fmt"
+
+	"github.com/lukasmalkmus/hkcode/hk/tlv8"
+)
+
+// message is a pair setup message. The state item is always sent first.
+type message struct {
+	State         uint8      `tlv8:"6"`
+	Method        *uint8     `tlv8:"0"`
+	Salt          []byte     `tlv8:"2,omitempty"`
+	PublicKey     []byte     `tlv8:"3,omitempty"`
+	Proof         []byte     `tlv8:"4,omitempty"`
+	EncryptedData []byte     `tlv8:"5,omitempty"`
+	Error         *ErrorCode `tlv8:"7"`
+}
+
+// subMessage is the encrypted sub-message sent in M5 and M6.
+type subMessage struct {
+	Identifier string `tlv8:"1"`
+	PublicKey  []byte `tlv8:"3"`
+	Signature  []byte `tlv8:"10"`
+}
+
+// encode returns the TLV8 encoding of v. It panics if v can't be encoded which
+// is only the case for programming errors as the message types are fixed.
+func encode(v any) []byte {
+	b, err := tlv8.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// decodeMessage decodes a message and checks its state.
+func decodeMessage(b []byte, wantState byte) (message, error) {
+	var msg message
+	if err := tlv8.Unmarshal(b, &msg); err != nil {
+		return message{}, fmt.Errorf("%w: %v", ErrUnexpectedMessage, err)
+	}
+
+	if msg.State != wantState {
+		return message{}, fmt.Errorf("%w: want state M%d", ErrUnexpectedMessage, wantState)
+	}
+	return msg, nil
+}
@@ -0,0 +1,174 @@
+package pairing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/lukasmalkmus/hkcode/hk/tlv8"
+)
+
+// ErrorCode is a pair setup error code as sent in the error TLV item.
+type ErrorCode byte
+
+// All available error codes.
+const (
+	ErrorUnknown        ErrorCode = 0x01 // unknown error
+	ErrorAuthentication ErrorCode = 0x02 // authentication error
+	ErrorBackoff        ErrorCode = 0x03 // too many failed attempts, retry later
+	ErrorMaxPeers       ErrorCode = 0x04 // no space for more pairings
+	ErrorMaxTries       ErrorCode = 0x05 // too many failed authentication attempts
+	ErrorUnavailable    ErrorCode = 0x06 // pairing is unavailable
+	ErrorBusy           ErrorCode = 0x07 // busy with another pairing
+)
+
+// Error returns a description of the error code.
+//
+// Implements [error].
+func (c ErrorCode) Error() string {
+	switch c {
+	case ErrorUnknown:
+		return "unknown error"
+	case ErrorAuthentication:
+		return "authentication error"
+	case ErrorBackoff:
+		return "too many failed attempts, retry later"
+	case ErrorMaxPeers:
+		return "no space for more pairings"
+	case ErrorMaxTries:
+		return "too many failed authentication attempts"
+	case ErrorUnavailable:
+		return "pairing is unavailable"
+	case ErrorBusy:
+		return "busy with another pairing"
+	}
+	return fmt.Sprintf("error code %d", byte(c))
+}
+
+// ErrUnexpectedMessage is returned when a message is received that doesn't fit
+// the current state of the pair setup procedure or lacks required items.
+var ErrUnexpectedMessage = fmt.Errorf("unexpected message")
+
+// methodPairSetup is the pair setup method without MFi authentication.
+const methodPairSetup = 0x00
+
+// Keys and nonces used by pair setup.
+const (
+	encryptSalt         = "Pair-Setup-Encrypt-Salt"
+	encryptInfo         = "Pair-Setup-Encrypt-Info"
+	controllerSignSalt  = "Pair-Setup-Controller-Sign-Salt"
+	controllerSignInfo  = "Pair-Setup-Controller-Sign-Info"
+	accessorySignSalt   = "Pair-Setup-Accessory-Sign-Salt"
+	accessorySignInfo   = "Pair-Setup-Accessory-Sign-Info"
+	controllerNonceText = "PS-Msg05"
+	accessoryNonceText  = "PS-Msg06"
+)
+
+// Peer is the result of a pair setup procedure: The pairing identifier and the
+// long-term public key of the other party.
+type Peer struct {
+	ID        string
+	PublicKey ed25519.PublicKey
+}
+
+// deriveKey derives a 32 byte key from the SRP session key using HKDF-SHA-512.
+func deriveKey(sessionKey []byte, salt, info string) ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha512.New, sessionKey, []byte(salt), []byte(info)), key); err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	return key, nil
+}
+
+// nonce returns the 12 byte nonce for the given 8 byte nonce text, padded with
+// leading zeros.
+func nonce(text string) []byte {
+	return append(make([]byte, chacha20poly1305.NonceSize-len(text)), text...)
+}
+
+func seal(key []byte, nonceText string, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, nonce(nonceText), plaintext, nil), nil
+}
+
+func open(key []byte, nonceText string, ciphertext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce(nonceText), ciphertext, nil)
+}
+
+// newSubMessage creates the encrypted sub-message sent in M5 and M6. It
+// proves the ownership of the long-term key and ties it to the SRP session.
+func newSubMessage(sessionKey []byte, signSalt, signInfo, nonceText, id string, privateKey ed25519.PrivateKey) ([]byte, error) {
+	x, err := deriveKey(sessionKey, signSalt, signInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	info := append(append(x, id...), publicKey...)
+
+	subTLV := encode(subMessage{
+		Identifier: id,
+		PublicKey:  publicKey,
+		Signature:  ed25519.Sign(privateKey, info),
+	})
+
+	key, err := deriveKey(sessionKey, encryptSalt, encryptInfo)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, nonceText, subTLV)
+}
+
+// openSubMessage decrypts and verifies the sub-message sent in M5 and M6.
+func openSubMessage(sessionKey []byte, signSalt, signInfo, nonceText string, encryptedData []byte) (Peer, error) {
+	key, err := deriveKey(sessionKey, encryptSalt, encryptInfo)
+	if err != nil {
+		return Peer{}, err
+	}
+
+	subTLV, err := open(key, nonceText, encryptedData)
+	if err != nil {
+		return Peer{}, fmt.Errorf("decrypt: %w", ErrorAuthentication)
+	}
+
+	var sub subMessage
+	if err := tlv8.Unmarshal(subTLV, &sub); err != nil {
+		return Peer{}, fmt.Errorf("%w: %v", ErrUnexpectedMessage, err)
+	}
+
+	id, publicKey, signature := sub.Identifier, sub.PublicKey, sub.Signature
+	if len(id) == 0 || len(publicKey) != ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return Peer{}, fmt.Errorf("%w: missing or malformed identifier, public key or signature", ErrUnexpectedMessage)
+	}
+
+	x, err := deriveKey(sessionKey, signSalt, signInfo)
+	if err != nil {
+		return Peer{}, err
+	}
+
+	info := append(append(x, id...), publicKey...)
+	if !ed25519.Verify(publicKey, info, signature) {
+		return Peer{}, fmt.Errorf("verify signature: %w", ErrorAuthentication)
+	}
+
+	return Peer{ID: id, PublicKey: publicKey}, nil
+}
+
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
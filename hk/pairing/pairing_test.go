@@ -0,0 +1,87 @@
+package pairing_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/pairing"
+	"github.com/lukasmalkmus/hkcode/hk/srp"
+)
+
+var deviceID = hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91}
+
+func TestSimulate(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier(12344321, nil)
+	require.NoError(t, err)
+
+	res, err := pairing.Simulate(12344321, deviceID, salt, verifier, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "AC:1F:74:0B:5E:91", res.Accessory.ID)
+	assert.Len(t, res.Accessory.PublicKey, 32)
+	assert.Len(t, res.Controller.ID, 36)
+	assert.Len(t, res.Controller.PublicKey, 32)
+}
+
+func TestSimulate_WrongCode(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier(12344321, nil)
+	require.NoError(t, err)
+
+	_, err = pairing.Simulate(12344322, deviceID, salt, verifier, nil)
+	require.ErrorIs(t, err, pairing.ErrorAuthentication)
+	assert.EqualError(t, err, "controller: M4: accessory reported: authentication error")
+}
+
+func TestSimulate_WrongSalt(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier(12344321, nil)
+	require.NoError(t, err)
+	salt[0] ^= 0xff
+
+	_, err = pairing.Simulate(12344321, deviceID, salt, verifier, nil)
+	require.ErrorIs(t, err, pairing.ErrorAuthentication)
+}
+
+func TestController_Handle(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier(12344321, nil)
+	require.NoError(t, err)
+
+	controller, err := pairing.NewController(12344321, nil)
+	require.NoError(t, err)
+
+	accessory, err := pairing.NewAccessory(deviceID, salt, verifier, nil)
+	require.NoError(t, err)
+
+	m1 := controller.Start()
+
+	// Messages out of order are rejected.
+	_, err = controller.Handle(m1)
+	assert.ErrorIs(t, err, pairing.ErrUnexpectedMessage)
+
+	m2, err := accessory.Handle(m1)
+	require.NoError(t, err)
+
+	m3, err := controller.Handle(m2)
+	require.NoError(t, err)
+
+	m4, err := accessory.Handle(m3)
+	require.NoError(t, err)
+
+	m5, err := controller.Handle(m4)
+	require.NoError(t, err)
+
+	m6, err := accessory.Handle(m5)
+	require.NoError(t, err)
+	assert.True(t, accessory.Done())
+	assert.Equal(t, controller.ID(), accessory.Controller().ID)
+	assert.Equal(t, controller.PublicKey(), accessory.Controller().PublicKey)
+
+	m7, err := controller.Handle(m6)
+	require.NoError(t, err)
+	assert.Nil(t, m7)
+	assert.True(t, controller.Done())
+	assert.Equal(t, accessory.ID(), controller.Accessory().ID)
+	assert.Equal(t, accessory.PublicKey(), controller.Accessory().PublicKey)
+}
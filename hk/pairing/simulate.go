@@ -0,0 +1,52 @@
+package pairing
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// Result is the result of a successful pair setup simulation.
+type Result struct {
+	Controller Peer
+	Accessory  Peer
+}
+
+// Simulate runs the pair setup procedure M1-M6 between a controller knowing
+// the given setup code and an accessory knowing the given device id, SRP salt
+// and verifier. It returns an error if the procedure fails, most notably one
+// wrapping [ErrorAuthentication] if the setup code doesn't match the verifier.
+// Randomness is read from r which should be cryptographically secure. If r is
+// nil, [crypto/rand.Reader] is used.
+func Simulate(code hk.Code, deviceID hk.DeviceID, salt, verifier []byte, r io.Reader) (Result, error) {
+	controller, err := NewController(code, r)
+	if err != nil {
+		return Result{}, fmt.Errorf("create controller: %w", err)
+	}
+
+	accessory, err := NewAccessory(deviceID, salt, verifier, r)
+	if err != nil {
+		return Result{}, fmt.Errorf("create accessory: %w", err)
+	}
+
+	msg := controller.Start()
+	for state := 1; !controller.Done(); state += 2 {
+		// Errors reported to the controller are more descriptive and
+		// returned below, so the accessory's error is only returned if it
+		// has no response.
+		res, err := accessory.Handle(msg)
+		if res == nil && err != nil {
+			return Result{}, fmt.Errorf("accessory: M%d: %w", state, err)
+		}
+
+		if msg, err = controller.Handle(res); err != nil {
+			return Result{}, fmt.Errorf("controller: M%d: %w", state+1, err)
+		}
+	}
+
+	return Result{
+		Controller: accessory.Controller(),
+		Accessory:  controller.Accessory(),
+	}, nil
+}
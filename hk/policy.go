@@ -0,0 +1,106 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrTrivialCode is returned when the [Code] is a trivial one. Trivial codes
+// are forbidden by the HomeKit Accessory Protocol Specification.
+var ErrTrivialCode = fmt.Errorf("%w: trivial", ErrInvalidCode)
+
+// ErrWeakCode is returned when the [Code] follows an easily guessable pattern.
+var ErrWeakCode = fmt.Errorf("%w: weak", ErrInvalidCode)
+
+// Policy describes additional rules a [Code] must comply with. Policies can be
+// combined.
+type Policy uint8
+
+// All available policies.
+const (
+	PolicyNone          Policy = 0         // <none>
+	PolicyTrivial       Policy = 1 << iota // Trivial
+	PolicyRepeatedPairs                    // Repeated Pairs
+	PolicyCycles                           // Cycles
+	PolicyPalindromes                      // Palindromes
+	PolicyDates                            // Dates
+
+	// PolicyStrict rejects all codes forbidden by the HomeKit Accessory
+	// Protocol Specification.
+	PolicyStrict = PolicyTrivial
+	// PolicyWeak rejects codes that are not forbidden but follow an easily
+	// guessable pattern.
+	PolicyWeak = PolicyRepeatedPairs | PolicyCycles | PolicyPalindromes | PolicyDates
+)
+
+// Check returns an error if the code is not valid or does not comply with the
+// policy. Errors for trivial codes wrap [ErrTrivialCode], errors for weak codes
+// wrap [ErrWeakCode]. All errors wrap [ErrInvalidCode].
+func (p Policy) Check(c Code) error {
+	if !c.Valid() {
+		return ErrInvalidCode
+	}
+
+	if p&PolicyTrivial != 0 && c.Trivial() {
+		return fmt.Errorf("%w: %s", ErrTrivialCode, c)
+	}
+
+	s := c.String()
+	switch {
+	case p&PolicyRepeatedPairs != 0 && hasPeriod(s, 2):
+		return fmt.Errorf("%w: %s consists of a repeated pair of digits", ErrWeakCode, s)
+	case p&PolicyCycles != 0 && (hasPeriod(s, 3) || hasPeriod(s, 4)):
+		return fmt.Errorf("%w: %s consists of a short repeated cycle of digits", ErrWeakCode, s)
+	case p&PolicyPalindromes != 0 && isPalindrome(s):
+		return fmt.Errorf("%w: %s is a palindrome", ErrWeakCode, s)
+	case p&PolicyDates != 0 && isDate(s):
+		return fmt.Errorf("%w: %s looks like a date", ErrWeakCode, s)
+	}
+
+	return nil
+}
+
+// GenerateCode generates a random setup code complying with the policy. Codes
+// are drawn like by [GenerateCode], the ones which don't comply are discarded.
+func (p Policy) GenerateCode(r io.Reader) (Code, error) {
+	for {
+		c, err := GenerateCode(r)
+		if err != nil {
+			return 0, err
+		}
+		if p.Check(c) == nil {
+			return c, nil
+		}
+	}
+}
+
+// hasPeriod returns true if s repeats itself after n characters.
+func hasPeriod(s string, n int) bool {
+	for i := n; i < len(s); i++ {
+		if s[i] != s[i-n] {
+			return false
+		}
+	}
+	return true
+}
+
+func isPalindrome(s string) bool {
+	for i := 0; i < len(s)/2; i++ {
+		if s[i] != s[len(s)-1-i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isDate returns true if the eight digit string s is a date in the YYYYMMDD,
+// DDMMYYYY or MMDDYYYY layout between 1900 and 2099.
+func isDate(s string) bool {
+	for _, layout := range []string{"20060102", "02012006", "01022006"} {
+		if t, err := time.Parse(layout, s); err == nil && t.Year() >= 1900 && t.Year() < 2100 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package hk_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func TestCode_Trivial(t *testing.T) {
+	for _, c := range []hk.Code{0, 11111111, 22222222, 55555555, 99999999, 12345678, 87654321} {
+		assert.True(t, c.Trivial(), c.String())
+	}
+	for _, c := range []hk.Code{1, 11111112, 12344321, 23456789, 98765432, 100000000, 111111110} {
+		assert.False(t, c.Trivial(), c.String())
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  hk.Policy
+		code    hk.Code
+		wantErr error
+	}{
+		{
+			name:   "none - trivial",
+			policy: hk.PolicyNone,
+			code:   11111111,
+		},
+		{
+			name:    "none - invalid",
+			policy:  hk.PolicyNone,
+			code:    100000000,
+			wantErr: hk.ErrInvalidCode,
+		},
+		{
+			name:    "strict - trivial",
+			policy:  hk.PolicyStrict,
+			code:    11111111,
+			wantErr: hk.ErrTrivialCode,
+		},
+		{
+			name:    "strict - trivial sequence",
+			policy:  hk.PolicyStrict,
+			code:    87654321,
+			wantErr: hk.ErrTrivialCode,
+		},
+		{
+			name:   "strict - weak",
+			policy: hk.PolicyStrict,
+			code:   12121212,
+		},
+		{
+			name:    "repeated pairs",
+			policy:  hk.PolicyRepeatedPairs,
+			code:    12121212,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "cycles - three digits",
+			policy:  hk.PolicyCycles,
+			code:    12312312,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "cycles - four digits",
+			policy:  hk.PolicyCycles,
+			code:    43214321,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "palindromes",
+			policy:  hk.PolicyPalindromes,
+			code:    12344321,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "dates - YYYYMMDD",
+			policy:  hk.PolicyDates,
+			code:    19870412,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "dates - DDMMYYYY",
+			policy:  hk.PolicyDates,
+			code:    31122024,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:    "dates - MMDDYYYY",
+			policy:  hk.PolicyDates,
+			code:    12312024,
+			wantErr: hk.ErrWeakCode,
+		},
+		{
+			name:   "dates - invalid day",
+			policy: hk.PolicyDates,
+			code:   20230230,
+		},
+		{
+			name:   "weak - random",
+			policy: hk.PolicyStrict | hk.PolicyWeak,
+			code:   38571946,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.code)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorIs(t, err, hk.ErrInvalidCode)
+		})
+	}
+}
+
+func TestPolicy_GenerateCode(t *testing.T) {
+	// A repeated pair and a palindrome are discarded.
+	r := bytes.NewReader([]byte{
+		0x00, 0xb8, 0xf4, 0x7c,
+		0x00, 0xbc, 0x5c, 0x01,
+		0x01, 0x78, 0x9b, 0x8d,
+	})
+
+	code, err := (hk.PolicyStrict | hk.PolicyWeak).GenerateCode(r)
+	require.NoError(t, err)
+	assert.EqualValues(t, 24681357, code)
+
+	_, err = hk.PolicyWeak.GenerateCode(r)
+	assert.Error(t, err)
+
+	for i := 0; i < 100; i++ {
+		code, err := hk.PolicyWeak.GenerateCode(nil)
+		require.NoError(t, err)
+		assert.NoError(t, (hk.PolicyStrict | hk.PolicyWeak).Check(code))
+	}
+}
@@ -0,0 +1,109 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// Matrix is the module matrix of a QR code, without a quiet zone. It is the
+// basis of all renderers and can be used to draw codes in other formats.
+type Matrix struct {
+	modules [][]bool
+	version int
+	mask    int
+	ecc     qrcode.RecoveryLevel
+}
+
+// Encode encodes the QR code payload for the setup information, see
+// [CreatePayload], into a module matrix. The payload is uppercase, so it is
+// encoded in the alphanumeric mode and the smallest possible version is used.
+// Only the [WithPolicy] and [WithRecovery] options apply. The error recovery
+// level defaults to [qrcode.High], like for [CreateCode].
+func Encode(info hk.SetupInfo, opts ...Option) (*Matrix, error) {
+	o := newOptions(opts).plain()
+	return encode(info, o, opts)
+}
+
+// Size returns the number of modules per side.
+func (m *Matrix) Size() int {
+	return len(m.modules)
+}
+
+// Get reports whether the module at (x, y) is dark. The origin is the top left
+// corner. Modules outside the matrix are light, like the quiet zone.
+func (m *Matrix) Get(x, y int) bool {
+	return x >= 0 && y >= 0 && x < len(m.modules) && y < len(m.modules) && m.modules[y][x]
+}
+
+// Version returns the version of the QR code, from 1 to 40. Version v has
+// 17+4v modules per side.
+func (m *Matrix) Version() int {
+	return m.version
+}
+
+// Mask returns the data mask pattern applied to the QR code, from 0 to 7.
+func (m *Matrix) Mask() int {
+	return m.mask
+}
+
+// ECC returns the error correction level of the QR code.
+func (m *Matrix) ECC() qrcode.RecoveryLevel {
+	return m.ecc
+}
+
+// encode validates the options and returns the module matrix of the QR code
+// for the setup information.
+func encode(info hk.SetupInfo, o options, opts []Option) (*Matrix, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	payload, err := CreatePayload(info, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create payload: %w", err)
+	}
+
+	// Only uppercase letters are part of the alphanumeric character set.
+	qrc, err := qrcode.New(strings.ToUpper(payload), *o.recovery)
+	if err != nil {
+		return nil, fmt.Errorf("create QR code: %w", err)
+	}
+	qrc.DisableBorder = true
+
+	modules := qrc.Bitmap()
+	if err := o.checkQuietZone(len(modules)); err != nil {
+		return nil, err
+	}
+	return &Matrix{
+		modules: modules,
+		version: qrc.VersionNumber,
+		mask:    formatMask(modules),
+		ecc:     *o.recovery,
+	}, nil
+}
+
+// formatMask returns the mask pattern stored in the format information of
+// the modules. Its 15 bits are placed, least significant first, from right to
+// left below the top right finder pattern and then from top to bottom right of
+// the bottom left finder pattern. The top 5 bits hold the error correction
+// level and the mask pattern, XORed with a fixed pattern.
+func formatMask(modules [][]bool) int {
+	const formatXOR = 0x5412
+
+	size := len(modules)
+	var format int
+	for i := 0; i < 15; i++ {
+		x, y := size-1-i, 8
+		if i >= 8 {
+			x, y = 8, size-15+i
+		}
+		if modules[y][x] {
+			format |= 1 << i
+		}
+	}
+	return (format ^ formatXOR) >> 10 & 0x7
+}
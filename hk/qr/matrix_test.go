@@ -0,0 +1,76 @@
+package qr_test
+
+import (
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+func TestEncode(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+	payload, err := qr.CreatePayload(info)
+	require.NoError(t, err)
+
+	// The 20 character payload fits into version 1 at the medium level only
+	// in the alphanumeric mode.
+	tests := []struct {
+		level   qrcode.RecoveryLevel
+		zxing   decoder.ErrorCorrectionLevel
+		version int
+	}{
+		{qrcode.Low, decoder.ErrorCorrectionLevel_L, 1},
+		{qrcode.Medium, decoder.ErrorCorrectionLevel_M, 1},
+		{qrcode.High, decoder.ErrorCorrectionLevel_Q, 2},
+		{qrcode.Highest, decoder.ErrorCorrectionLevel_H, 2},
+	}
+	for _, tt := range tests {
+		m, err := qr.Encode(info, qr.WithRecovery(tt.level))
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.version, m.Version())
+		assert.Equal(t, 17+4*tt.version, m.Size())
+		assert.Equal(t, tt.level, m.ECC())
+		assert.False(t, m.Get(-1, 0))
+		assert.False(t, m.Get(0, m.Size()))
+
+		// Read the matrix with an independent decoder.
+		bits, err := gozxing.NewSquareBitMatrix(m.Size())
+		require.NoError(t, err)
+		for y := 0; y < m.Size(); y++ {
+			for x := 0; x < m.Size(); x++ {
+				if m.Get(x, y) {
+					bits.Set(x, y)
+				}
+			}
+		}
+		parser, err := decoder.NewBitMatrixParser(bits)
+		require.NoError(t, err)
+		format, err := parser.ReadFormatInformation()
+		require.NoError(t, err)
+		assert.EqualValues(t, format.GetDataMask(), m.Mask())
+		assert.Equal(t, tt.zxing, format.GetErrorCorrectionLevel())
+
+		res, err := decoder.NewDecoder().Decode(bits, nil)
+		require.NoError(t, err)
+		assert.Equal(t, payload, res.GetText())
+	}
+
+	m, err := qr.Encode(info)
+	require.NoError(t, err)
+	assert.Equal(t, qrcode.High, m.ECC())
+
+	_, err = qr.Encode(hk.SetupInfo{Code: 11111111, ID: "RFGD"}, qr.WithPolicy(hk.PolicyStrict))
+	assert.ErrorIs(t, err, hk.ErrTrivialCode)
+}
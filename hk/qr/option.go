@@ -0,0 +1,246 @@
+package qr
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	hkfont "github.com/lukasmalkmus/hkcode/hk/font"
+	"github.com/lukasmalkmus/hkcode/internal/assets"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// An Option modifies the creation of a setup code.
+type Option func(*options)
+
+type options struct {
+	policy    hk.Policy
+	font      hkfont.Provider
+	size      int
+	recovery  *qrcode.RecoveryLevel
+	quietZone *int
+	fg, bg    color.Color
+	dpi       float64
+	printSize float64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		font: hkfont.Fallback(hkfont.SFMonoBold, hkfont.GoMonoBold),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// plain returns the options with the defaults of plain codes applied.
+func (o options) plain() options {
+	if o.printSize > 0 && o.dpi > 0 {
+		o.size = resolution.Pixels(o.printSize, o.dpi)
+	}
+	if o.size == 0 {
+		o.size = codeSize
+	}
+	if o.recovery == nil {
+		o.recovery = recoveryPtr(qrcode.High)
+	}
+	if o.quietZone == nil {
+		o.quietZone = intPtr(4)
+	}
+	if o.fg == nil {
+		o.fg = color.Black
+	}
+	if o.bg == nil {
+		o.bg = color.White
+	}
+	return o
+}
+
+// boxed returns the options with the defaults of boxed codes applied. The
+// colors are left unset if not given, so the colors of the box template are
+// used.
+func (o options) boxed() options {
+	if o.printSize > 0 && o.dpi > 0 {
+		o.size = resolution.Pixels(o.printSize, o.dpi)
+	}
+	if o.size == 0 {
+		o.size = assets.BoxWidth
+	}
+	if o.recovery == nil {
+		o.recovery = recoveryPtr(qrcode.Medium)
+	}
+	if o.quietZone == nil {
+		o.quietZone = intPtr(0)
+	}
+	return o
+}
+
+// validate returns an error if an option has an invalid value. The print size
+// is checked before the size, as the size follows from it.
+func (o options) validate() error {
+	switch {
+	case math.IsNaN(o.dpi) || math.IsInf(o.dpi, 0):
+		return fmt.Errorf("invalid resolution %s DPI: must be finite", vector.FormatFloat(o.dpi))
+	case math.IsNaN(o.printSize) || math.IsInf(o.printSize, 0):
+		return fmt.Errorf("invalid print size %s mm: must be finite", vector.FormatFloat(o.printSize))
+	case o.dpi < 0:
+		return fmt.Errorf("invalid resolution %s DPI: must not be negative", vector.FormatFloat(o.dpi))
+	case o.printSize < 0:
+		return fmt.Errorf("invalid print size %s mm: must not be negative", vector.FormatFloat(o.printSize))
+	case o.printSize > 0 && o.dpi > 0 && o.printSize/resolution.MMPerInch*o.dpi > MaxSize:
+		return fmt.Errorf("invalid print size %s mm at %s DPI: must be at most %d pixels wide",
+			vector.FormatFloat(o.printSize), vector.FormatFloat(o.dpi), MaxSize)
+	case o.size < 0:
+		return fmt.Errorf("invalid size %d: must not be negative", o.size)
+	case o.size > MaxSize:
+		return fmt.Errorf("invalid size %d: must be at most %d", o.size, MaxSize)
+	case o.recovery != nil && (*o.recovery < qrcode.Low || *o.recovery > qrcode.Highest):
+		return fmt.Errorf("invalid recovery level %d", *o.recovery)
+	case o.quietZone != nil && *o.quietZone < 0:
+		return fmt.Errorf("invalid quiet zone %d: must not be negative", *o.quietZone)
+	case o.quietZone != nil && *o.quietZone > MaxSize/2:
+		return fmt.Errorf("invalid quiet zone %d: must be at most %d", *o.quietZone, MaxSize/2)
+	}
+	return nil
+}
+
+// checkQuietZone returns an error if the modules, surrounded by the quiet
+// zone, are more than [MaxSize] wide. Codes have at least a pixel per module.
+func (o options) checkQuietZone(modules int) error {
+	if width := modules + 2**o.quietZone; width > MaxSize {
+		return fmt.Errorf("invalid quiet zone %d: code would be %d modules wide, at most %d fit",
+			*o.quietZone, width, MaxSize)
+	}
+	return nil
+}
+
+// printWidth returns the width of the code on paper in millimetres or 0 if it
+// is unknown. The size must be resolved.
+func (o options) printWidth() float64 {
+	switch {
+	case o.printSize > 0:
+		return o.printSize
+	case o.dpi > 0:
+		return resolution.MM(float64(o.size), o.dpi)
+	}
+	return 0
+}
+
+// checkModules returns an error wrapping [ErrTooSmall] if the code would be
+// printed with modules smaller than [MinModuleSize] or, if it is a raster
+// image, [MinModulePixels]. The modules, including the quiet zone, span the
+// given fraction of the width of the code. Codes without a print size are
+// never too small.
+func (o options) checkModules(modules int, fraction float64, raster bool) error {
+	if raster && o.printSize > 0 && o.dpi == 0 {
+		return fmt.Errorf("print size %s mm needs a resolution", vector.FormatFloat(o.printSize))
+	}
+
+	width := o.printWidth()
+	if width == 0 {
+		return nil
+	}
+	if size := width * fraction / float64(modules); size < MinModuleSize {
+		return fmt.Errorf("%w: modules are %s mm wide, need at least %s mm",
+			ErrTooSmall, vector.FormatFloat(size), vector.FormatFloat(MinModuleSize))
+	}
+	if raster && o.dpi > 0 {
+		if size := float64(o.size) * fraction / float64(modules); size < MinModulePixels {
+			return fmt.Errorf("%w: modules are %s pixels wide at %s DPI, need at least %d pixels",
+				ErrTooSmall, vector.FormatFloat(size), vector.FormatFloat(o.dpi), MinModulePixels)
+		}
+	}
+	return nil
+}
+
+// WithPolicy rejects setup codes which do not comply with the given policy.
+// By default, only invalid setup codes are rejected. Use [hk.PolicyStrict] to
+// reject the trivial codes forbidden by the HomeKit Accessory Protocol
+// Specification.
+func WithPolicy(policy hk.Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithFont sets the font the digits of boxed codes and the captions of sheets
+// are set in. By default, SF Mono Bold is used where available and Go Mono Bold
+// elsewhere, see [hkfont.Fallback].
+func WithFont(font hkfont.Provider) Option {
+	return func(o *options) {
+		o.font = font
+	}
+}
+
+// WithSize sets the width of the code in pixels. Plain codes are square and
+// 256 pixels wide by default. Boxed codes keep the aspect ratio of the box and
+// are 400 pixels wide by default. Plain codes are enlarged if the size is
+// smaller than the number of modules, including the quiet zone. A size of 0
+// uses the default. Sizes above [MaxSize] are rejected.
+func WithSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithRecovery sets the error recovery level of the QR code. Higher levels can
+// be read even if more of the code is damaged or obscured but need more
+// modules. By default, plain codes use [qrcode.High] and boxed codes
+// [qrcode.Medium].
+func WithRecovery(level qrcode.RecoveryLevel) Option {
+	return func(o *options) {
+		o.recovery = &level
+	}
+}
+
+// WithQuietZone sets the width of the empty border around the QR code in
+// modules. Plain codes have the 4 modules required by the QR code
+// specification by default. Boxed codes have none by default as the box
+// surrounds the code with enough space. Codes wider than [MaxSize] modules,
+// including the quiet zone, are rejected.
+func WithQuietZone(modules int) Option {
+	return func(o *options) {
+		o.quietZone = &modules
+	}
+}
+
+// WithColors sets the foreground and background color. The foreground color
+// is used for the modules and, on boxed codes, the digits, frame and logo. By
+// default, plain codes are black on white. Boxed codes have the colors of the
+// box template by default and a transparent background.
+func WithColors(fg, bg color.Color) Option {
+	return func(o *options) {
+		o.fg, o.bg = fg, bg
+	}
+}
+
+// WithDPI sets the resolution the code is printed at in dots per inch. It is
+// stored in the files written by [WritePNG] and [WriteBoxedPNG] and gives the
+// code a print size, see [WithPrintSize].
+func WithDPI(dpi float64) Option {
+	return func(o *options) {
+		o.dpi = dpi
+	}
+}
+
+// WithPrintSize sets the width of the code on paper in millimetres. Raster
+// images need a resolution, see [WithDPI], and their size in pixels follows
+// from both, overriding [WithSize]. SVG documents are given the print size as
+// their physical size.
+//
+// Codes with a print size are rejected with [ErrTooSmall] if their modules
+// would be too small to be scanned reliably.
+func WithPrintSize(mm float64) Option {
+	return func(o *options) {
+		o.printSize = mm
+	}
+}
+
+func recoveryPtr(level qrcode.RecoveryLevel) *qrcode.RecoveryLevel { return &level }
+
+func intPtr(i int) *int { return &i }
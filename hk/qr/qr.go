@@ -5,39 +5,82 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
+	"math"
+	"strings"
 
-	"github.com/skip2/go-qrcode"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 
 	"github.com/lukasmalkmus/hkcode/hk"
 	"github.com/lukasmalkmus/hkcode/internal/assets"
-	embeddedFont "github.com/lukasmalkmus/hkcode/internal/assets/font"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
 )
 
-// CreateCode creates a QR code based Apple HomeKit® setup code.
-func CreateCode(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, category hk.Category) (image.Image, error) {
-	payload, err := CreatePayload(setupCode, setupID, setupFlags, category)
-	if err != nil {
-		return nil, fmt.Errorf("create payload: %w", err)
-	}
+// ErrTooSmall is returned when a code with a print size would have modules too
+// small to be scanned reliably, see [WithPrintSize].
+var ErrTooSmall = fmt.Errorf("code too small to scan")
+
+const (
+	// MinModuleSize is the minimum width of a module of a printed code in
+	// millimetres.
+	MinModuleSize = 0.25
+	// MinModulePixels is the minimum width of a module of a printed raster
+	// image in pixels. Smaller modules are distorted too much by rounding
+	// them to whole pixels.
+	MinModulePixels = 2
+	// MaxSize is the maximum width of a code in pixels, including the quiet
+	// zone. Larger codes would need too much memory.
+	MaxSize = 8192
+)
 
-	qrc, err := qrcode.New(payload, qrcode.High)
+// CreateCode creates a QR code based Apple HomeKit® setup code for the given
+// setup information, see [CreatePayload]. Its size, error recovery level,
+// quiet zone and colors can be changed with [WithSize], [WithRecovery],
+// [WithQuietZone] and [WithColors]. To print it, give it a print size with
+// [WithPrintSize] and [WithDPI] instead of a size in pixels.
+func CreateCode(info hk.SetupInfo, opts ...Option) (image.Image, error) {
+	o := newOptions(opts).plain()
+
+	m, err := encode(info, o, opts)
 	if err != nil {
-		return nil, fmt.Errorf("create QR code: %w", err)
+		return nil, err
+	}
+	if err := o.checkModules(m.Size()+2**o.quietZone, 1, true); err != nil {
+		return nil, err
 	}
 
-	return qrc.Image(256), nil
+	return modulesImage(m, *o.quietZone, o.size, o.fg, o.bg), nil
 }
 
 // CreateBoxedCode creates a QR code based Apple HomeKit® setup code that is
 // placed inside a bordered box with the Apple HomeKit® logo and the setup code
 // in plain text. These codes are usually found as stickers on MFi accessories.
-func CreateBoxedCode(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, category hk.Category) (image.Image, error) {
-	payload, err := CreatePayload(setupCode, setupID, setupFlags, category)
+// The options of [CreateCode] apply as well.
+func CreateBoxedCode(info hk.SetupInfo, opts ...Option) (image.Image, error) {
+	o := newOptions(opts).boxed()
+
+	m, err := encode(info, o, opts)
 	if err != nil {
-		return nil, fmt.Errorf("create payload: %w", err)
+		return nil, err
+	}
+	if err := o.checkModules(m.Size()+2**o.quietZone, boxedCodeFraction, true); err != nil {
+		return nil, err
+	}
+
+	// Codes of other sizes or colors than the box template are drawn from its
+	// vector shapes.
+	if o.size != assets.BoxWidth || o.fg != nil || o.bg != nil {
+		shapes, err := boxedShapes(info, m, o)
+		if err != nil {
+			return nil, err
+		}
+		s := float64(o.size) / assets.BoxWidth
+		img := image.NewNRGBA(image.Rect(0, 0, o.size, int(math.Round(assets.BoxHeight*s))))
+		vector.Draw(img, s, shapes...)
+		return img, nil
 	}
 
 	img, err := assets.Box()
@@ -50,20 +93,13 @@ func CreateBoxedCode(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categ
 		return nil, fmt.Errorf("%T is not a drawable image type", img)
 	}
 
-	otf, err := embeddedFont.SFMonoBold()
+	otf, err := o.font.Font()
 	if err != nil {
 		return nil, fmt.Errorf("load font: %w", err)
 	}
 
-	qrc, err := qrcode.New(payload, qrcode.Medium)
-	if err != nil {
-		return nil, fmt.Errorf("create QR code: %w", err)
-	}
-	qrc.DisableBorder = true
-	qrc.BackgroundColor = color.Transparent
-
-	qrImg := qrc.Image(320)
-	offset := image.Point{X: 40, Y: 180}
+	qrImg := modulesImage(m, *o.quietZone, boxedCodeSize, color.Black, color.Transparent)
+	offset := image.Point{X: boxedCodeX, Y: boxedCodeY}
 
 	draw.Draw(dimg, qrImg.Bounds().Add(offset), qrImg, image.Point{}, draw.Src)
 
@@ -83,7 +119,7 @@ func CreateBoxedCode(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categ
 		Face: face,
 	}
 
-	codeStr := setupCode.String()
+	codeStr := info.Code.String()
 
 	for i := 0; i < 4; i++ {
 		fd.Dot = fixed.Point26_6{
@@ -102,28 +138,91 @@ func CreateBoxedCode(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categ
 	return img, nil
 }
 
+// WritePNG writes a QR code based Apple HomeKit® setup code as created by
+// [CreateCode] as PNG to w. The resolution set by [WithDPI] is stored in it, so
+// it is printed at its print size.
+func WritePNG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	img, err := CreateCode(info, opts...)
+	if err != nil {
+		return err
+	}
+	return resolution.EncodePNG(w, img, newOptions(opts).dpi)
+}
+
+// WriteBoxedPNG writes a QR code based Apple HomeKit® setup code as created by
+// [CreateBoxedCode] as PNG to w. The resolution set by [WithDPI] is stored in
+// it, so it is printed at its print size.
+func WriteBoxedPNG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	img, err := CreateBoxedCode(info, opts...)
+	if err != nil {
+		return err
+	}
+	return resolution.EncodePNG(w, img, newOptions(opts).dpi)
+}
+
+// Position and size of the QR code on the box template in pixels.
+const (
+	boxedCodeX    = 40
+	boxedCodeY    = 180
+	boxedCodeSize = 320
+)
+
+// boxedCodeFraction is the fraction of the width of a boxed code taken up by
+// the QR code.
+const boxedCodeFraction = float64(boxedCodeSize) / assets.BoxWidth
+
+// modulesImage returns a square image of the given size showing the modules
+// surrounded by a quiet zone of the given width in modules. Like
+// [qrcode.QRCode.Image], each pixel is mapped to the nearest module and the
+// image is enlarged if it has fewer pixels than modules.
+func modulesImage(m *Matrix, quietZone, size int, fg, bg color.Color) *image.Paletted {
+	realSize := m.Size() + 2*quietZone
+	if size < realSize {
+		size = realSize
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+
+	modulesPerPixel := float64(realSize) / float64(size)
+	for y := 0; y < size; y++ {
+		y2 := int(float64(y)*modulesPerPixel) - quietZone
+		if y2 < 0 || y2 >= m.Size() {
+			continue
+		}
+		for x := 0; x < size; x++ {
+			x2 := int(float64(x)*modulesPerPixel) - quietZone
+			if m.Get(x2, y2) {
+				img.Pix[img.PixOffset(x, y)] = 1
+			}
+		}
+	}
+
+	return img
+}
+
 const base36 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
 // CreatePayload creates a QR code payload for the given Apple HomeKit® setup
-// code.
-func CreatePayload(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, category hk.Category) (string, error) {
+// information. It must be valid, see [hk.SetupInfo.Validate], and setup codes
+// are checked against the policy set by [WithPolicy]. The optional accessory
+// information is not part of the payload.
+func CreatePayload(info hk.SetupInfo, opts ...Option) (string, error) {
+	o := newOptions(opts)
+
 	// Validate and normalize input.
-	if !setupCode.Valid() {
-		return "", hk.ErrInvalidCode
-	} else if !setupID.Valid() {
-		return "", hk.ErrInvalidID
+	if err := info.Validate(); err != nil {
+		return "", err
+	} else if err := o.policy.Check(info.Code); err != nil {
+		return "", err
 	}
 
-	// Changing these will break the code as it will not be recognized by
-	// Apple Devices anymore.
-	const (
-		version  = 0
-		reserved = 0
-	)
+	// Changing this will break the code as it will not be recognized by Apple
+	// Devices anymore.
+	const reserved = 0
 
 	// Bits 45-43: The "Version" field (0-7). Always set to 0.
 	var payload uint64
-	payload |= (version & 0x7)
+	payload |= (uint64(info.Version) & 0x7)
 
 	// Bits 42-39: The "Reserved" field (0-15). Always set to 0.
 	payload <<= 4
@@ -131,7 +230,7 @@ func CreatePayload(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categor
 
 	// Bits 38-31: The accessory type (0-255).
 	payload <<= 8
-	payload |= (uint64(category) & 0xff)
+	payload |= (uint64(info.Category) & 0xff)
 
 	// Bits 30-27: The setup flags (supported pairing methods, 0-15). Seem to be
 	// ignored by Apple devices.
@@ -140,11 +239,11 @@ func CreatePayload(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categor
 	// Bit 28: Set to 1 if IP pairing is supported, else set to 0.
 	// Bit 27: Set to 1 if NFC pairing is supported, else set to 0.
 	payload <<= 4
-	payload |= (uint64(setupFlags) & 0xf)
+	payload |= (uint64(info.Flags) & 0xf)
 
 	// Bits 26-0 - The 8-digit setup code (from 0-99999999).
 	payload <<= 27
-	payload |= (uint64(setupCode) & 0x7fffffff)
+	payload |= (uint64(info.Code) & 0x7ffffff)
 
 	// The result must be 9 digits. If less, pad with leading zeros. Encode as
 	// Base 36.
@@ -155,5 +254,83 @@ func CreatePayload(setupCode hk.Code, setupID hk.ID, setupFlags hk.Flag, categor
 		payload /= 36
 	}
 
-	return fmt.Sprintf("X-HM://%s%s", encodedPayload, setupID), nil
+	// Always use the canonical, uppercased setup id.
+	return fmt.Sprintf("X-HM://%s%s", encodedPayload, info.ID.String()), nil
+}
+
+// ErrInvalidPayload is returned when a QR code payload can't be parsed.
+var ErrInvalidPayload = fmt.Errorf("invalid setup payload")
+
+// Payload is the decoded content of a QR code payload as created by
+// [CreatePayload]. The optional accessory information of the setup information
+// is never set.
+type Payload struct {
+	hk.SetupInfo
+	// Reserved bits of the payload. Usually 0.
+	Reserved uint8
+}
+
+// ParsePayload parses a QR code payload of the form "X-HM://" followed by the
+// nine character base36 encoded setup information and the four character setup
+// id. It is the inverse of [CreatePayload].
+func ParsePayload(payload string) (Payload, error) {
+	const (
+		prefix     = "X-HM://"
+		dataLength = 9
+		idLength   = 4
+	)
+
+	if len(payload) < len(prefix) || !strings.EqualFold(payload[:len(prefix)], prefix) {
+		return Payload{}, fmt.Errorf("%w: missing %q prefix", ErrInvalidPayload, prefix)
+	}
+	payload = payload[len(prefix):]
+
+	if l := len(payload); l != dataLength+idLength {
+		return Payload{}, fmt.Errorf("%w: unexpected length %d, want %d",
+			ErrInvalidPayload, len(prefix)+l, len(prefix)+dataLength+idLength)
+	}
+
+	// Decode the Base 36 encoded data. Lowercase characters are accepted as the
+	// payload might have been transcribed by a human.
+	var data uint64
+	for i := 0; i < dataLength; i++ {
+		c := payload[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		digit := strings.IndexByte(base36, c)
+		if digit < 0 {
+			return Payload{}, fmt.Errorf("%w: invalid base36 character %q at position %d",
+				ErrInvalidPayload, payload[i], len(prefix)+i)
+		}
+		data = data*36 + uint64(digit)
+	}
+
+	// Only 46 bits are used, see CreatePayload for the layout.
+	if data>>46 != 0 {
+		return Payload{}, fmt.Errorf("%w: data exceeds 46 bits", ErrInvalidPayload)
+	}
+
+	res := Payload{
+		SetupInfo: hk.SetupInfo{
+			Version:  uint8((data >> 43) & 0x7),
+			Category: hk.Category((data >> 31) & 0xff),
+			Flags:    hk.Flag((data >> 27) & 0xf),
+			Code:     hk.Code(data & 0x7ffffff),
+		},
+		Reserved: uint8((data >> 39) & 0xf),
+	}
+
+	if res.Version != 0 {
+		return Payload{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidPayload, res.Version)
+	} else if !res.Code.Valid() {
+		return Payload{}, fmt.Errorf("%w: %d is out of range", hk.ErrInvalidCode, uint32(res.Code))
+	}
+
+	var err error
+	if res.ID, err = hk.ParseID(payload[dataLength:]); err != nil {
+		return Payload{}, err
+	}
+
+	return res, nil
 }
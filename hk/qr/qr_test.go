@@ -1,12 +1,23 @@
 package qr_test
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
 	"testing"
 
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/lukasmalkmus/hkcode/hk"
+	hkfont "github.com/lukasmalkmus/hkcode/hk/font"
 	"github.com/lukasmalkmus/hkcode/hk/qr"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
 	"github.com/lukasmalkmus/hkcode/internal/testdata"
 	"github.com/lukasmalkmus/hkcode/internal/testutil"
 )
@@ -14,17 +25,370 @@ import (
 func TestCreateCode(t *testing.T) {
 	golden := testdata.GetGoldenQRCodeImage(t)
 
-	img, err := qr.CreateCode(12344321, "RFGD", hk.FlagIP|hk.FlagBTLE, hk.CategorySwitch)
+	img, err := qr.CreateCode(hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	})
 	require.NoError(t, err)
 
 	testutil.AssertEqualImage(t, golden, img)
 }
 
 func TestCreateBoxedCode(t *testing.T) {
-	golden := testdata.GetGoldenBoxedQRCodeImage(t)
+	for _, name := range hkfont.Names() {
+		t.Run(name, func(t *testing.T) {
+			font, _ := hkfont.Lookup(name)
+			if _, err := font.Font(); errors.Is(err, hkfont.ErrUnavailable) {
+				t.Skip(err)
+			}
 
-	img, err := qr.CreateBoxedCode(12345678, "RFGD", hk.FlagIP|hk.FlagBTLE, hk.CategorySwitch)
+			golden := testdata.GetGoldenBoxedQRCodeImage(t, name)
+
+			img, err := qr.CreateBoxedCode(hk.SetupInfo{
+				Code:     12345678,
+				ID:       "RFGD",
+				Flags:    hk.FlagIP | hk.FlagBTLE,
+				Category: hk.CategorySwitch,
+			}, qr.WithFont(font))
+			require.NoError(t, err)
+
+			testutil.AssertEqualImage(t, golden, img)
+		})
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    qr.Payload
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			payload: "X-HM://008MYPTKXRFGD",
+			want: qr.Payload{SetupInfo: hk.SetupInfo{
+				Code:     12344321,
+				ID:       "RFGD",
+				Flags:    hk.FlagIP | hk.FlagBTLE,
+				Category: hk.CategorySwitch,
+			}},
+		},
+		{
+			name:    "valid - lowercase",
+			payload: "x-hm://008myptkxrfgd",
+			want: qr.Payload{SetupInfo: hk.SetupInfo{
+				Code:     12344321,
+				ID:       "RFGD",
+				Flags:    hk.FlagIP | hk.FlagBTLE,
+				Category: hk.CategorySwitch,
+			}},
+		},
+		{
+			name:    "valid - reserved bits",
+			payload: "X-HM://0ZBEQSXS1RFGD",
+			want: qr.Payload{
+				SetupInfo: hk.SetupInfo{
+					Code:     12344321,
+					ID:       "RFGD",
+					Flags:    hk.FlagIP | hk.FlagBTLE,
+					Category: hk.CategorySwitch,
+				},
+				Reserved: 5,
+			},
+		},
+		{
+			name:    "invalid - missing prefix",
+			payload: "008MYPTKXRFGD",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - too short",
+			payload: "X-HM://008MYPTKXRFG",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - too long",
+			payload: "X-HM://008MYPTKXRFGDA",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - base36 character",
+			payload: "X-HM://008MYP-KXRFGD",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - version",
+			payload: "X-HM://34HI9E70HRFGD",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - exceeds 46 bits",
+			payload: "X-HM://ZZZZZZZZZRFGD",
+			wantErr: qr.ErrInvalidPayload,
+		},
+		{
+			name:    "invalid - setup id",
+			payload: "X-HM://008MYPTKXRF-D",
+			wantErr: hk.ErrInvalidID,
+		},
+		{
+			name:    "invalid - code out of range",
+			payload: "X-HM://0001NJCHSRFGD",
+			wantErr: hk.ErrInvalidCode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qr.ParsePayload(tt.payload)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePayload_RoundTrip(t *testing.T) {
+	for _, category := range []hk.Category{hk.CategoryUnknown, hk.CategoryBridge, hk.CategoryShowerSystems, 255} {
+		for _, setupCode := range []hk.Code{0, 12344321, 99999999} {
+			info := hk.SetupInfo{
+				Code:     setupCode,
+				ID:       "AB12",
+				Flags:    hk.FlagNFC | hk.FlagBTLE,
+				Category: category,
+			}
+
+			payload, err := qr.CreatePayload(info)
+			require.NoError(t, err)
+
+			got, err := qr.ParsePayload(payload)
+			require.NoError(t, err)
+
+			assert.Equal(t, qr.Payload{SetupInfo: info}, got)
+		}
+	}
+}
+
+func TestCreatePayload_SetupID(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "rfgd",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+
+	payload, err := qr.CreatePayload(info)
 	require.NoError(t, err)
+	assert.Equal(t, "X-HM://008MYPTKXRFGD", payload)
 
-	testutil.AssertEqualImage(t, golden, img)
+	info.ID = "rf-d"
+	_, err = qr.CreatePayload(info)
+	assert.ErrorIs(t, err, hk.ErrInvalidID)
+}
+
+func TestCreatePayload_Invalid(t *testing.T) {
+	_, err := qr.CreatePayload(hk.SetupInfo{
+		Code:    100000000,
+		ID:      "RFGD",
+		Flags:   hk.Flag(16),
+		Version: 1,
+	})
+	assert.ErrorIs(t, err, hk.ErrInvalidCode)
+	assert.ErrorIs(t, err, hk.ErrInvalidFlag)
+	assert.ErrorContains(t, err, "unsupported version 1")
+}
+
+func TestCreatePayload_Policy(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     11111111,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP,
+		Category: hk.CategorySwitch,
+	}
+
+	_, err := qr.CreatePayload(info)
+	require.NoError(t, err)
+
+	_, err = qr.CreatePayload(info, qr.WithPolicy(hk.PolicyStrict))
+	require.ErrorIs(t, err, hk.ErrTrivialCode)
+
+	info.Code = 12344321
+	_, err = qr.CreatePayload(info, qr.WithPolicy(hk.PolicyStrict|hk.PolicyWeak))
+	require.ErrorIs(t, err, hk.ErrWeakCode)
+}
+
+func TestCreateCode_Options(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+	fg, bg := color.RGBA{R: 0x20, G: 0x20, B: 0x80, A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xe0, A: 0xff}
+
+	img, err := qr.CreateCode(info, qr.WithSize(1000), qr.WithQuietZone(2), qr.WithColors(fg, bg))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1000, 1000), img.Bounds())
+	assertColor(t, bg, img.At(0, 0))
+	// The top left finder pattern starts after the quiet zone.
+	assertColor(t, fg, img.At(1000*2/29+10, 1000*2/29+10))
+
+	got, err := qr.ScanImage(img)
+	require.NoError(t, err)
+	assert.Equal(t, qr.Payload{SetupInfo: info}, got)
+
+	// Too small images are enlarged to one pixel per module. Higher recovery
+	// levels need more modules.
+	img, err = qr.CreateCode(info, qr.WithSize(1), qr.WithRecovery(qrcode.Low), qr.WithQuietZone(0))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 21, 21), img.Bounds())
+
+	img, err = qr.CreateCode(info, qr.WithSize(1), qr.WithRecovery(qrcode.Highest), qr.WithQuietZone(0))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 25, 25), img.Bounds())
+}
+
+func TestCreateBoxedCode_Options(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+
+	for _, opts := range [][]qr.Option{
+		{qr.WithSize(1200)},
+		{qr.WithSize(300), qr.WithRecovery(qrcode.Highest)},
+		{qr.WithColors(color.Black, color.White), qr.WithQuietZone(2)},
+	} {
+		img, err := qr.CreateBoxedCode(info, opts...)
+		require.NoError(t, err)
+
+		got, err := qr.ScanImage(img)
+		require.NoError(t, err)
+		assert.Equal(t, qr.Payload{SetupInfo: info}, got)
+	}
+
+	img, err := qr.CreateBoxedCode(info, qr.WithSize(800), qr.WithColors(color.Black, color.White))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 800, 1078), img.Bounds())
+	assertColor(t, color.Transparent, img.At(0, 0))
+	assertColor(t, color.White, img.At(60, 600))
+}
+
+func TestCreateCode_InvalidOptions(t *testing.T) {
+	info := hk.SetupInfo{Code: 12344321, ID: "RFGD"}
+
+	tests := []struct {
+		name    string
+		create  func(hk.SetupInfo, ...qr.Option) (image.Image, error)
+		opts    []qr.Option
+		wantErr string
+	}{
+		{"negative size", qr.CreateCode, []qr.Option{qr.WithSize(-1)}, "invalid size -1: must not be negative"},
+		{"huge size", qr.CreateCode, []qr.Option{qr.WithSize(2000000)}, "invalid size 2000000: must be at most 8192"},
+		{"huge boxed size", qr.CreateBoxedCode, []qr.Option{qr.WithSize(2000000)}, "invalid size 2000000: must be at most 8192"},
+		{"NaN resolution", qr.CreateCode, []qr.Option{qr.WithDPI(math.NaN())}, "invalid resolution NaN DPI: must be finite"},
+		{"infinite resolution", qr.CreateCode, []qr.Option{qr.WithDPI(math.Inf(1))}, "invalid resolution +Inf DPI: must be finite"},
+		{"NaN print size", qr.CreateBoxedCode, []qr.Option{qr.WithPrintSize(math.NaN())}, "invalid print size NaN mm: must be finite"},
+		{
+			"infinite print size", qr.CreateCode, []qr.Option{qr.WithPrintSize(math.Inf(-1)), qr.WithDPI(300)},
+			"invalid print size -Inf mm: must be finite",
+		},
+		{"recovery level", qr.CreateBoxedCode, []qr.Option{qr.WithRecovery(qrcode.Highest + 1)}, "invalid recovery level 4"},
+		{"negative quiet zone", qr.CreateCode, []qr.Option{qr.WithQuietZone(-4)}, "invalid quiet zone -4: must not be negative"},
+		{"huge quiet zone", qr.CreateCode, []qr.Option{qr.WithQuietZone(1 << 40)}, "invalid quiet zone 1099511627776: must be at most 4096"},
+		{
+			"quiet zone too wide", qr.CreateCode, []qr.Option{qr.WithQuietZone(4090)},
+			"invalid quiet zone 4090: code would be 8205 modules wide, at most 8192 fit",
+		},
+		{
+			"huge print size", qr.CreateCode, []qr.Option{qr.WithPrintSize(1000), qr.WithDPI(1200)},
+			"invalid print size 1000 mm at 1200 DPI: must be at most 8192 pixels wide",
+		},
+		{
+			"huge resolution", qr.CreateBoxedCode, []qr.Option{qr.WithPrintSize(15), qr.WithDPI(1e6)},
+			"invalid print size 15 mm at 1000000 DPI: must be at most 8192 pixels wide",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.create(info, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	var buf bytes.Buffer
+	err := qr.WriteSVG(&buf, info, qr.WithQuietZone(1<<40))
+	assert.EqualError(t, err, "invalid quiet zone 1099511627776: must be at most 4096")
+}
+
+func TestWritePNG_PrintSize(t *testing.T) {
+	info := hk.SetupInfo{Code: 12344321, ID: "RFGD"}
+
+	for name, write := range map[string]func(io.Writer, hk.SetupInfo, ...qr.Option) error{
+		"plain": qr.WritePNG,
+		"boxed": qr.WriteBoxedPNG,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, write(&buf, info, qr.WithPrintSize(15), qr.WithDPI(600)))
+
+			dpi, ok := resolution.PNGDPI(buf.Bytes())
+			require.True(t, ok)
+			assert.InDelta(t, 600, dpi, 0.01)
+
+			img, err := png.Decode(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, 354, img.Bounds().Dx())
+
+			got, err := qr.ScanImage(img)
+			require.NoError(t, err)
+			assert.Equal(t, info, got.SetupInfo)
+		})
+	}
+
+	// Without a resolution, no pHYs chunk is written.
+	var buf bytes.Buffer
+	require.NoError(t, qr.WritePNG(&buf, info))
+	_, ok := resolution.PNGDPI(buf.Bytes())
+	assert.False(t, ok)
+}
+
+func TestCreateCode_TooSmall(t *testing.T) {
+	info := hk.SetupInfo{Code: 12344321, ID: "RFGD"}
+
+	// 33 modules, including the quiet zone, on 5 mm.
+	_, err := qr.CreateCode(info, qr.WithPrintSize(5), qr.WithDPI(1200))
+	require.ErrorIs(t, err, qr.ErrTooSmall)
+	assert.EqualError(t, err, "code too small to scan: modules are 0.152 mm wide, need at least 0.25 mm")
+
+	// 43 pixels for 33 modules.
+	_, err = qr.CreateCode(info, qr.WithPrintSize(15), qr.WithDPI(72))
+	require.ErrorIs(t, err, qr.ErrTooSmall)
+	assert.EqualError(t, err, "code too small to scan: modules are 1.303 pixels wide at 72 DPI, need at least 2 pixels")
+
+	// A size in pixels has a print size at a given resolution, too.
+	_, err = qr.CreateBoxedCode(info, qr.WithSize(100), qr.WithDPI(1200))
+	assert.ErrorIs(t, err, qr.ErrTooSmall)
+
+	_, err = qr.CreateCode(info, qr.WithPrintSize(15))
+	assert.EqualError(t, err, "print size 15 mm needs a resolution")
+
+	_, err = qr.CreateCode(info, qr.WithDPI(-300))
+	assert.EqualError(t, err, "invalid resolution -300 DPI: must not be negative")
+}
+
+func assertColor(t *testing.T, want, got color.Color) {
+	t.Helper()
+
+	wr, wg, wb, wa := want.RGBA()
+	gr, gg, gb, ga := got.RGBA()
+	assert.Equal(t, [4]uint32{wr, wg, wb, wa}, [4]uint32{gr, gg, gb, ga})
 }
@@ -0,0 +1,32 @@
+package qr
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/makiuchi-d/gozxing"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ErrNoCode is returned when no QR code could be found in an image.
+var ErrNoCode = fmt.Errorf("no QR code found")
+
+// ScanImage locates the QR code in the given image, reads its payload and
+// parses it using [ParsePayload]. It works on plain QR codes as created by
+// [CreateCode] as well as on boxed ones as created by [CreateBoxedCode], be it
+// rendered ones or scans and photos of printed stickers.
+func ScanImage(img image.Image) (Payload, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return Payload{}, fmt.Errorf("binarize image: %w", err)
+	}
+
+	res, err := zxingqr.NewQRCodeReader().Decode(bmp, map[gozxing.DecodeHintType]any{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	})
+	if err != nil {
+		return Payload{}, fmt.Errorf("%w: %v", ErrNoCode, err)
+	}
+
+	return ParsePayload(res.GetText())
+}
@@ -0,0 +1,82 @@
+package qr_test
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+	"github.com/lukasmalkmus/hkcode/internal/testdata"
+)
+
+func TestScanImage(t *testing.T) {
+	want := qr.Payload{SetupInfo: hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}}
+
+	plain, err := qr.CreateCode(want.SetupInfo)
+	require.NoError(t, err)
+
+	boxed, err := qr.CreateBoxedCode(want.SetupInfo)
+	require.NoError(t, err)
+
+	wantGolden := want
+	wantGolden.Code = 12345678
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want qr.Payload
+	}{
+		{"plain", plain, want},
+		{"plain - rotated", rotate(plain), want},
+		{"plain - embedded", embed(plain), want},
+		{"boxed", boxed, want},
+		{"boxed - rotated", rotate(boxed), want},
+		{"boxed - golden", testdata.GetGoldenBoxedQRCodeImage(t, "sf-mono-bold"), wantGolden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qr.ScanImage(tt.img)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanImage_NoCode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	_, err := qr.ScanImage(img)
+	assert.ErrorIs(t, err, qr.ErrNoCode)
+}
+
+// rotate rotates the image by 90 degrees clockwise.
+func rotate(img image.Image) image.Image {
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			res.Set(b.Max.Y-y-1, x-b.Min.X, img.At(x, y))
+		}
+	}
+	return res
+}
+
+// embed places the image off-center on a larger white canvas.
+func embed(img image.Image) image.Image {
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, b.Dx()*3, b.Dy()*2))
+	draw.Draw(res, res.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(res, b.Add(image.Pt(b.Dx()*2-20, 30)), img, b.Min, draw.Over)
+	return res
+}
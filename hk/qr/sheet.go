@@ -0,0 +1,344 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/internal/assets"
+	"github.com/lukasmalkmus/hkcode/internal/pdf"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// Layout describes a sheet of labels, like the label stock the codes are
+// printed on. All lengths are in millimetres.
+type Layout struct {
+	// PageWidth and PageHeight are the size of the page.
+	PageWidth, PageHeight float64
+	// MarginTop and MarginLeft are the distance of the first label from the
+	// top and left edge of the page.
+	MarginTop, MarginLeft float64
+	// LabelWidth and LabelHeight are the size of a label.
+	LabelWidth, LabelHeight float64
+	// PitchX and PitchY are the distances between the top left corners of
+	// horizontally and vertically adjacent labels. If zero, the label width
+	// and height are used, so labels touch each other.
+	PitchX, PitchY float64
+	// Columns and Rows are the number of labels per row and column.
+	Columns, Rows int
+	// Padding is the minimum distance of the code from the label edges.
+	Padding float64
+}
+
+// Common label sheet layouts.
+var (
+	// LayoutAveryL7651 is Avery L7651: 65 labels of 38.1 x 21.2 mm on A4.
+	LayoutAveryL7651 = Layout{
+		PageWidth:   210,
+		PageHeight:  297,
+		MarginTop:   10.7,
+		MarginLeft:  4.75,
+		LabelWidth:  38.1,
+		LabelHeight: 21.2,
+		PitchX:      40.6,
+		PitchY:      21.2,
+		Columns:     5,
+		Rows:        13,
+		Padding:     1,
+	}
+	// LayoutAvery5160 is Avery 5160: 30 labels of 2 5/8 x 1 inch on US Letter.
+	LayoutAvery5160 = Layout{
+		PageWidth:   215.9,
+		PageHeight:  279.4,
+		MarginTop:   12.7,
+		MarginLeft:  4.7625,
+		LabelWidth:  66.675,
+		LabelHeight: 25.4,
+		PitchX:      69.85,
+		PitchY:      25.4,
+		Columns:     3,
+		Rows:        10,
+		Padding:     1.5,
+	}
+	// LayoutSquare30 are 54 square labels of 30 x 30 mm with 2 mm gaps on A4.
+	LayoutSquare30 = Layout{
+		PageWidth:   210,
+		PageHeight:  297,
+		MarginTop:   5.5,
+		MarginLeft:  10,
+		LabelWidth:  30,
+		LabelHeight: 30,
+		PitchX:      32,
+		PitchY:      32,
+		Columns:     6,
+		Rows:        9,
+		Padding:     1,
+	}
+)
+
+// Validate returns all problems of the layout at once, joined by
+// [errors.Join], or nil if it is valid.
+func (l Layout) Validate() error {
+	// Allow for rounding errors of layouts given in inches.
+	const epsilon = 0.01
+
+	for _, v := range []float64{
+		l.PageWidth, l.PageHeight, l.MarginTop, l.MarginLeft,
+		l.LabelWidth, l.LabelHeight, l.PitchX, l.PitchY, l.Padding,
+	} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("lengths must be finite")
+		}
+	}
+
+	var errs []error
+	if l.PageWidth <= 0 || l.PageHeight <= 0 {
+		errs = append(errs, fmt.Errorf("page size must be positive"))
+	}
+	if l.LabelWidth <= 0 || l.LabelHeight <= 0 {
+		errs = append(errs, fmt.Errorf("label size must be positive"))
+	}
+	if l.Columns <= 0 || l.Rows <= 0 {
+		errs = append(errs, fmt.Errorf("columns and rows must be positive"))
+	}
+	if l.MarginTop < 0 || l.MarginLeft < 0 || l.PitchX < 0 || l.PitchY < 0 || l.Padding < 0 {
+		errs = append(errs, fmt.Errorf("margins, pitch and padding must not be negative"))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	pitchX, pitchY := l.pitch()
+	if pitchX < l.LabelWidth-epsilon || pitchY < l.LabelHeight-epsilon {
+		errs = append(errs, fmt.Errorf("labels overlap: pitch %sx%s mm is smaller than label size %sx%s mm",
+			mm(pitchX), mm(pitchY), mm(l.LabelWidth), mm(l.LabelHeight)))
+	}
+	if w := l.MarginLeft + float64(l.Columns-1)*pitchX + l.LabelWidth; w > l.PageWidth+epsilon {
+		errs = append(errs, fmt.Errorf("%d columns need %s mm but the page is %s mm wide", l.Columns, mm(w), mm(l.PageWidth)))
+	}
+	if h := l.MarginTop + float64(l.Rows-1)*pitchY + l.LabelHeight; h > l.PageHeight+epsilon {
+		errs = append(errs, fmt.Errorf("%d rows need %s mm but the page is %s mm high", l.Rows, mm(h), mm(l.PageHeight)))
+	}
+	if 2*l.Padding >= math.Min(l.LabelWidth, l.LabelHeight) {
+		errs = append(errs, fmt.Errorf("padding %s mm leaves no space on the label", mm(l.Padding)))
+	}
+	return errors.Join(errs...)
+}
+
+// mm formats a length for error messages, hiding floating point noise.
+func mm(v float64) string {
+	return vector.FormatFloat(v)
+}
+
+func (l Layout) pitch() (float64, float64) {
+	pitchX, pitchY := l.PitchX, l.PitchY
+	if pitchX == 0 {
+		pitchX = l.LabelWidth
+	}
+	if pitchY == 0 {
+		pitchY = l.LabelHeight
+	}
+	return pitchX, pitchY
+}
+
+// Sheet configures how codes are printed on a sheet of labels.
+type Sheet struct {
+	Layout Layout
+	// Boxed prints boxed codes as created by [CreateBoxedCode] instead of plain
+	// ones.
+	Boxed bool
+	// Caption prints the serial number of the accessory, if any, below each
+	// code.
+	Caption bool
+	// CropMarks prints marks at the corners of each label, outside of the
+	// bleed, to guide cutting.
+	CropMarks bool
+	// Bleed is the distance in millimetres the label background extends beyond
+	// the label edges, so no unprinted edge remains if cutting is off.
+	Bleed float64
+	// Background is the color of the label background. If nil, no background
+	// is printed.
+	Background color.Color
+}
+
+// Caption font size limits in millimetres.
+const (
+	maxCaptionSize = 2.5
+	minCaptionSize = 1
+)
+
+// Crop mark dimensions in millimetres.
+const (
+	cropMarkOffset = 1
+	cropMarkLength = 3
+	cropMarkWidth  = 0.1
+)
+
+// WriteSheet writes a PDF document with a label for each of the setup infos
+// to w. Labels are laid out row by row and as many pages as needed are
+// created. Setup codes are checked against the policy set by [WithPolicy]
+// and labels too small for their codes to be scanned reliably are rejected
+// with [ErrTooSmall]. PDF documents are laid out in physical units, so the
+// print size options don't apply.
+func WriteSheet(w io.Writer, infos []hk.SetupInfo, sheet Sheet, opts ...Option) error {
+	l := sheet.Layout
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	} else if math.IsNaN(sheet.Bleed) || math.IsInf(sheet.Bleed, 0) || sheet.Bleed < 0 {
+		return fmt.Errorf("bleed must be finite and not negative")
+	}
+
+	otf, err := newOptions(opts).font.Font()
+	if err != nil {
+		return fmt.Errorf("load font: %w", err)
+	}
+
+	const k = pdf.PointsPerMM
+	var (
+		doc            = pdf.New()
+		page           *pdf.Page
+		perPage        = l.Columns * l.Rows
+		pitchX, pitchY = l.pitch()
+	)
+
+	// toPage maps millimetres with the origin in the top left corner of the
+	// page to PDF points, scaling by s and translating by (x, y) first.
+	toPage := func(s, x, y float64) pdf.Matrix {
+		return pdf.Matrix{k * s, 0, 0, -k * s, k * x, k * (l.PageHeight - y)}
+	}
+
+	for i, info := range infos {
+		if i%perPage == 0 {
+			page = doc.AddPage(l.PageWidth*k, l.PageHeight*k)
+		}
+		col, row := i%perPage%l.Columns, i%perPage/l.Columns
+		x := l.MarginLeft + float64(col)*pitchX
+		y := l.MarginTop + float64(row)*pitchY
+
+		width, height := float64(codeSize), float64(codeSize)
+		if sheet.Boxed {
+			width, height = assets.BoxWidth, assets.BoxHeight
+		}
+
+		if sheet.Background != nil {
+			var bg vector.Path
+			bg.Rect(x-sheet.Bleed, y-sheet.Bleed, l.LabelWidth+2*sheet.Bleed, l.LabelHeight+2*sheet.Bleed)
+			page.Draw(toPage(1, 0, 0), vector.Shape{Path: bg, Fill: sheet.Background})
+		}
+
+		// The area available for the code, without the caption.
+		areaX, areaY := x+l.Padding, y+l.Padding
+		areaW, areaH := l.LabelWidth-2*l.Padding, l.LabelHeight-2*l.Padding
+
+		if sheet.Caption && info.SerialNumber != "" {
+			caption, captionHeight, err := captionPath(otf, info.SerialNumber, areaW, areaH)
+			if err != nil {
+				return fmt.Errorf("label %d: %w", i+1, err)
+			}
+			areaH -= captionHeight
+			caption = caption.Transform(1, areaX, areaY+areaH)
+			page.Draw(toPage(1, 0, 0), vector.Shape{Path: caption, Fill: color.Black})
+		}
+
+		s := math.Min(areaW/width, areaH/height)
+
+		// Codes are checked against the minimum module size at the size they
+		// are printed at on the label.
+		var shapes []vector.Shape
+		o := newOptions(opts)
+		o.printSize, o.dpi = width*s, 0
+		if sheet.Boxed {
+			shapes, err = boxedCodeShapes(info, o.boxed(), opts)
+		} else {
+			shapes, err = codeShapes(info, o.plain(), opts)
+		}
+		if err != nil {
+			return fmt.Errorf("label %d: %w", i+1, err)
+		}
+
+		codeX := areaX + (areaW-width*s)/2
+		codeY := areaY + (areaH-height*s)/2
+		page.Draw(toPage(s, codeX, codeY), shapes...)
+
+		if sheet.CropMarks {
+			drawCropMarks(page, l, x, y, sheet.Bleed)
+		}
+	}
+
+	if len(infos) == 0 {
+		doc.AddPage(l.PageWidth*k, l.PageHeight*k)
+	}
+
+	_, err = doc.WriteTo(w)
+	return err
+}
+
+// captionPath returns the glyph outlines of the caption text, horizontally
+// centered in the given width, and the height it takes up. The text is
+// positioned relative to the top left corner of that space. It fails if the
+// caption takes up the whole height, leaving no space for the code.
+func captionPath(otf *sfnt.Font, text string, width, height float64) (vector.Path, float64, error) {
+	size := math.Min(maxCaptionSize, height*0.15)
+	textWidth, err := vector.TextWidth(otf, text, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("measure caption: %w", err)
+	}
+	// Shrink long captions to fit the width, but keep them legible.
+	if textWidth > width {
+		size = math.Max(minCaptionSize, size*width/textWidth)
+		textWidth, err = vector.TextWidth(otf, text, size)
+		if err != nil {
+			return nil, 0, fmt.Errorf("measure caption: %w", err)
+		}
+	}
+
+	ascent, err := vector.Ascent(otf, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get font metrics: %w", err)
+	}
+
+	// Leave some space between code and caption.
+	gap := size * 0.3
+	captionHeight := gap + size*1.2
+	if captionHeight >= height {
+		return nil, 0, fmt.Errorf("caption needs %s mm, leaving no space for the code on %s mm",
+			mm(captionHeight), mm(height))
+	}
+	path, err := vector.Text(otf, text, size, (width-textWidth)/2, gap+ascent)
+	if err != nil {
+		return nil, 0, fmt.Errorf("create caption outlines: %w", err)
+	}
+	return path, captionHeight, nil
+}
+
+// drawCropMarks draws the crop marks of the label with the top left corner
+// (x, y), given in millimetres.
+func drawCropMarks(page *pdf.Page, l Layout, x, y, bleed float64) {
+	const k = pdf.PointsPerMM
+
+	line := func(x1, y1, x2, y2 float64) {
+		page.Line(k*x1, k*(l.PageHeight-y1), k*x2, k*(l.PageHeight-y2), cropMarkWidth*k, color.Black)
+	}
+
+	start := bleed + cropMarkOffset
+	end := start + cropMarkLength
+	for _, cx := range []float64{x, x + l.LabelWidth} {
+		for _, cy := range []float64{y, y + l.LabelHeight} {
+			// Point the marks away from the label.
+			dx, dy := -1.0, -1.0
+			if cx > x {
+				dx = 1
+			}
+			if cy > y {
+				dy = 1
+			}
+			line(cx+dx*start, cy, cx+dx*end, cy)
+			line(cx, cy+dy*start, cx, cy+dy*end)
+		}
+	}
+}
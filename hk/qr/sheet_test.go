@@ -0,0 +1,122 @@
+package qr_test
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+func TestWriteSheet(t *testing.T) {
+	infos := make([]hk.SetupInfo, 70)
+	for i := range infos {
+		infos[i] = hk.SetupInfo{
+			Code:         hk.Code(10000000 + i),
+			ID:           "RFGD",
+			Category:     hk.CategorySwitch,
+			SerialNumber: fmt.Sprintf("SN-%04d", i),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		sheet qr.Sheet
+		pages int
+	}{
+		{"avery l7651", qr.Sheet{Layout: qr.LayoutAveryL7651}, 2},
+		{"avery 5160", qr.Sheet{Layout: qr.LayoutAvery5160, Caption: true}, 3},
+		{"square 30mm", qr.Sheet{
+			Layout:     qr.LayoutSquare30,
+			Boxed:      true,
+			Caption:    true,
+			CropMarks:  true,
+			Bleed:      1,
+			Background: color.White,
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, qr.WriteSheet(&buf, infos, tt.sheet))
+
+			assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+			assert.Contains(t, buf.String(), fmt.Sprintf("/Count %d >>", tt.pages))
+		})
+	}
+}
+
+func TestWriteSheet_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, qr.WriteSheet(&buf, nil, qr.Sheet{Layout: qr.LayoutAveryL7651}))
+	assert.Contains(t, buf.String(), "/Count 1 >>")
+}
+
+func TestWriteSheet_Error(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := qr.WriteSheet(&buf, []hk.SetupInfo{{Code: 12344321, ID: "RFGD"}, {Code: 11111111, ID: "RFGD"}}, qr.Sheet{Layout: qr.LayoutAveryL7651},
+		qr.WithPolicy(hk.PolicyStrict))
+	require.ErrorIs(t, err, hk.ErrTrivialCode)
+	assert.ErrorContains(t, err, "label 2: ")
+	assert.Zero(t, buf.Len())
+
+	err = qr.WriteSheet(&buf, nil, qr.Sheet{Layout: qr.LayoutAveryL7651, Bleed: -1})
+	assert.EqualError(t, err, "bleed must be finite and not negative")
+
+	err = qr.WriteSheet(&buf, nil, qr.Sheet{Layout: qr.LayoutAveryL7651, Bleed: math.NaN()})
+	assert.EqualError(t, err, "bleed must be finite and not negative")
+
+	err = qr.WriteSheet(&buf, nil, qr.Sheet{})
+	assert.ErrorContains(t, err, "invalid layout: ")
+
+	tiny := qr.Layout{PageWidth: 210, PageHeight: 297, LabelWidth: 8, LabelHeight: 8, Columns: 1, Rows: 1, Padding: 1}
+	err = qr.WriteSheet(&buf, []hk.SetupInfo{{Code: 12344321, ID: "RFGD"}}, qr.Sheet{Layout: tiny})
+	require.ErrorIs(t, err, qr.ErrTooSmall)
+	assert.ErrorContains(t, err, "label 1: ")
+
+	// The minimum caption size leaves no space for the code.
+	flat := qr.Layout{PageWidth: 210, PageHeight: 297, LabelWidth: 3, LabelHeight: 1.6, Columns: 1, Rows: 1, Padding: 0.1}
+	info := hk.SetupInfo{Code: 12344321, ID: "RFGD", SerialNumber: "HK-2024-000001-LONG-SERIAL"}
+	for _, boxed := range []bool{false, true} {
+		err = qr.WriteSheet(&buf, []hk.SetupInfo{info}, qr.Sheet{Layout: flat, Boxed: boxed, Caption: true})
+		assert.EqualError(t, err, "label 1: caption needs 1.5 mm, leaving no space for the code on 1.4 mm")
+	}
+	assert.Zero(t, buf.Len())
+}
+
+func TestLayout_Validate(t *testing.T) {
+	for _, l := range []qr.Layout{qr.LayoutAveryL7651, qr.LayoutAvery5160, qr.LayoutSquare30} {
+		assert.NoError(t, l.Validate())
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*qr.Layout)
+		err    string
+	}{
+		{"zero page", func(l *qr.Layout) { l.PageWidth = 0 }, "page size must be positive"},
+		{"zero label", func(l *qr.Layout) { l.LabelHeight = 0 }, "label size must be positive"},
+		{"zero grid", func(l *qr.Layout) { l.Columns = 0 }, "columns and rows must be positive"},
+		{"negative margin", func(l *qr.Layout) { l.MarginTop = -1 }, "margins, pitch and padding must not be negative"},
+		{"overlap", func(l *qr.Layout) { l.PitchX = 30 }, "labels overlap: pitch 30x21.2 mm is smaller than label size 38.1x21.2 mm"},
+		{"too wide", func(l *qr.Layout) { l.Columns = 6 }, "6 columns need 245.85 mm but the page is 210 mm wide"},
+		{"too high", func(l *qr.Layout) { l.Rows = 14 }, "14 rows need 307.5 mm but the page is 297 mm high"},
+		{"padding", func(l *qr.Layout) { l.Padding = 10.6 }, "padding 10.6 mm leaves no space on the label"},
+		{"NaN padding", func(l *qr.Layout) { l.Padding = math.NaN() }, "lengths must be finite"},
+		{"infinite page", func(l *qr.Layout) { l.PageWidth = math.Inf(1) }, "lengths must be finite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := qr.LayoutAveryL7651
+			tt.modify(&l)
+			assert.EqualError(t, l.Validate(), tt.err)
+		})
+	}
+}
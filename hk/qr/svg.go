@@ -0,0 +1,165 @@
+package qr
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/internal/assets"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// WriteSVG writes a QR code based Apple HomeKit® setup code as SVG to w. It is
+// the vector equivalent of [CreateCode]: Every module is drawn as part of a
+// single path, so the code stays sharp at any scale.
+func WriteSVG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	o := newOptions(opts).plain()
+	shapes, err := codeShapes(info, o, opts)
+	if err != nil {
+		return err
+	}
+	s := float64(o.size) / codeSize
+	return writeSVG(w, o, float64(o.size), float64(o.size), scaleShapes(shapes, s))
+}
+
+// WriteBoxedSVG writes a QR code based Apple HomeKit® setup code that is placed
+// inside a bordered box with the Apple HomeKit® logo and the setup code in
+// plain text as SVG to w. It is the vector equivalent of [CreateBoxedCode]: The
+// frame and logo are vector shapes and the digits are glyph outlines, so no
+// fonts are needed to display it.
+func WriteBoxedSVG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	o := newOptions(opts).boxed()
+	shapes, err := boxedCodeShapes(info, o, opts)
+	if err != nil {
+		return err
+	}
+	s := float64(o.size) / assets.BoxWidth
+	return writeSVG(w, o, float64(o.size), math.Round(assets.BoxHeight*s), scaleShapes(shapes, s))
+}
+
+// writeSVG writes an SVG document of the given size in pixels with the shapes
+// to w. It has the print size of the code as its physical size, if known.
+func writeSVG(w io.Writer, o options, width, height float64, shapes []vector.Shape) error {
+	if pw := o.printWidth(); pw > 0 {
+		return vector.WritePrintSVG(w, width, height, pw/width, shapes...)
+	}
+	return vector.WriteSVG(w, width, height, shapes...)
+}
+
+// codeSize is the default size of a plain code in pixels.
+const codeSize = 256
+
+// codeShapes returns the shapes of a plain code of the size codeSize. The
+// options must have the defaults of plain codes applied.
+func codeShapes(info hk.SetupInfo, o options, opts []Option) ([]vector.Shape, error) {
+	m, err := encode(info, o, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.checkModules(m.Size()+2**o.quietZone, 1, false); err != nil {
+		return nil, err
+	}
+
+	var background vector.Path
+	background.Rect(0, 0, codeSize, codeSize)
+
+	s := codeSize / float64(m.Size()+2**o.quietZone)
+	q := float64(*o.quietZone) * s
+	modules := modulePath(m).Transform(s, q, q)
+
+	return []vector.Shape{
+		{Path: background, Fill: o.bg},
+		{Path: modules, Fill: o.fg},
+	}, nil
+}
+
+// boxedCodeShapes returns the shapes of a boxed code of the size of the box
+// template. The options must have the defaults of boxed codes applied.
+func boxedCodeShapes(info hk.SetupInfo, o options, opts []Option) ([]vector.Shape, error) {
+	m, err := encode(info, o, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.checkModules(m.Size()+2**o.quietZone, boxedCodeFraction, false); err != nil {
+		return nil, err
+	}
+	return boxedShapes(info, m, o)
+}
+
+// boxedShapes returns the shapes of a boxed code with the given module matrix
+// like boxedCodeShapes.
+func boxedShapes(info hk.SetupInfo, m *Matrix, o options) ([]vector.Shape, error) {
+	otf, err := o.font.Font()
+	if err != nil {
+		return nil, fmt.Errorf("load font: %w", err)
+	}
+
+	s := boxedCodeSize / float64(m.Size()+2**o.quietZone)
+	q := float64(*o.quietZone) * s
+	modules := modulePath(m).Transform(s, boxedCodeX+q, boxedCodeY+q)
+
+	const fontSize = 69
+	ascent, err := vector.Ascent(otf, fontSize)
+	if err != nil {
+		return nil, fmt.Errorf("get font metrics: %w", err)
+	}
+
+	var digits vector.Path
+	codeStr := info.Code.String()
+	for i := 0; i < 4; i++ {
+		for j, baseline := range []float64{ascent + 25, ascent + 88} {
+			glyph, err := vector.Text(otf, string(codeStr[i+j*4]), fontSize, float64(173+i*49), baseline)
+			if err != nil {
+				return nil, fmt.Errorf("create glyph outlines: %w", err)
+			}
+			digits.Append(glyph)
+		}
+	}
+
+	fg := o.fg
+	if fg == nil {
+		fg = color.Black
+	}
+
+	var shapes []vector.Shape
+	if o.bg != nil {
+		shapes = append(shapes, vector.Shape{Path: assets.BoxFrame(), Fill: o.bg})
+	}
+	shapes = append(shapes, assets.BoxShapes(o.fg)...)
+	return append(shapes,
+		vector.Shape{Path: modules, Fill: fg},
+		vector.Shape{Path: digits, Fill: fg},
+	), nil
+}
+
+// scaleShapes returns copies of the shapes scaled by s.
+func scaleShapes(shapes []vector.Shape, s float64) []vector.Shape {
+	res := make([]vector.Shape, len(shapes))
+	for i, shape := range shapes {
+		res[i] = shape
+		res[i].Path = shape.Path.Transform(s, 0, 0)
+		res[i].StrokeWidth = shape.StrokeWidth * s
+	}
+	return res
+}
+
+// modulePath returns a path with one unit square for each dark module of the
+// matrix. Horizontally adjacent modules are merged into a single rectangle.
+func modulePath(m *Matrix) vector.Path {
+	var path vector.Path
+	for y := 0; y < m.Size(); y++ {
+		for x := 0; x < m.Size(); x++ {
+			if !m.Get(x, y) {
+				continue
+			}
+			start := x
+			for m.Get(x, y) {
+				x++
+			}
+			path.Rect(float64(start), float64(y), float64(x-start), 1)
+		}
+	}
+	return path
+}
@@ -0,0 +1,119 @@
+package qr_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/draw"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+func TestWriteSVG(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+
+	tests := []struct {
+		name   string
+		write  func(*bytes.Buffer) error
+		width  int
+		height int
+	}{
+		{"plain", func(b *bytes.Buffer) error { return qr.WriteSVG(b, info) }, 256, 256},
+		{"boxed", func(b *bytes.Buffer) error { return qr.WriteBoxedSVG(b, info) }, 400, 539},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tt.write(&buf))
+
+			img := rasterizeSVG(t, buf.Bytes(), tt.width, tt.height)
+
+			got, err := qr.ScanImage(img)
+			require.NoError(t, err)
+			assert.Equal(t, qr.Payload{SetupInfo: info}, got)
+		})
+	}
+}
+
+func TestWriteSVG_Policy(t *testing.T) {
+	info := hk.SetupInfo{Code: 11111111, ID: "RFGD"}
+
+	err := qr.WriteSVG(&bytes.Buffer{}, info, qr.WithPolicy(hk.PolicyStrict))
+	assert.ErrorIs(t, err, hk.ErrTrivialCode)
+
+	err = qr.WriteBoxedSVG(&bytes.Buffer{}, info, qr.WithPolicy(hk.PolicyStrict))
+	assert.ErrorIs(t, err, hk.ErrTrivialCode)
+}
+
+func TestWriteSVG_PrintSize(t *testing.T) {
+	info := hk.SetupInfo{Code: 12344321, ID: "RFGD"}
+
+	var buf bytes.Buffer
+	require.NoError(t, qr.WriteSVG(&buf, info, qr.WithPrintSize(15)))
+	assert.Contains(t, buf.String(), `width="15mm" height="15mm" viewBox="0 0 256 256"`)
+
+	buf.Reset()
+	require.NoError(t, qr.WriteBoxedSVG(&buf, info, qr.WithSize(800), qr.WithDPI(400)))
+	assert.Contains(t, buf.String(), `width="50.8mm" height="68.453mm" viewBox="0 0 800 1078"`)
+
+	err := qr.WriteSVG(&buf, info, qr.WithPrintSize(5))
+	assert.ErrorIs(t, err, qr.ErrTooSmall)
+}
+
+var rectRe = regexp.MustCompile(`M([\d.]+) ([\d.]+)L([\d.]+) [\d.]+L[\d.]+ ([\d.]+)L[\d.]+ [\d.]+Z`)
+
+// rasterizeSVG checks that data is a well-formed SVG document of the given size
+// and draws all of its black, rectangle-only paths, which are the QR code
+// modules, onto a white image.
+func rasterizeSVG(t *testing.T, data []byte, width, height int) image.Image {
+	t.Helper()
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Width   int      `xml:"width,attr"`
+		Height  int      `xml:"height,attr"`
+		Paths   []struct {
+			D    string `xml:"d,attr"`
+			Fill string `xml:"fill,attr"`
+		} `xml:"path"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	require.Equal(t, width, doc.Width)
+	require.Equal(t, height, doc.Height)
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	parse := func(s string) int {
+		f, err := strconv.ParseFloat(s, 64)
+		require.NoError(t, err)
+		return int(f + 0.5)
+	}
+
+	var modules int
+	for _, path := range doc.Paths {
+		if path.Fill != "#000000" || rectRe.ReplaceAllString(path.D, "") != "" {
+			continue
+		}
+		for _, m := range rectRe.FindAllStringSubmatch(path.D, -1) {
+			r := image.Rect(parse(m[1]), parse(m[2]), parse(m[3]), parse(m[4]))
+			draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+			modules++
+		}
+	}
+	require.NotZero(t, modules)
+
+	return img
+}
@@ -0,0 +1,58 @@
+package qr
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// ANSI escape sequences to switch inverse video on and to reset all
+// attributes.
+const (
+	ansiInverse = "\x1b[7m"
+	ansiReset   = "\x1b[0m"
+)
+
+// WriteTerminal writes a QR code based Apple HomeKit® setup code as text for
+// display in a terminal to w. Two rows of modules are drawn per line with the
+// Unicode half block characters. The lines are printed in ANSI inverse video,
+// so the modules are dark on a light background on terminals with light text
+// on a dark background. The error recovery level and quiet zone can be changed
+// like for [CreateCode], the other options don't apply.
+func WriteTerminal(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	o := newOptions(opts).plain()
+
+	m, err := encode(info, o, opts)
+	if err != nil {
+		return err
+	}
+
+	qz := *o.quietZone
+	size := m.Size() + 2*qz
+	dark := func(x, y int) bool {
+		return m.Get(x-qz, y-qz)
+	}
+
+	// In inverse video, the glyphs have the background color of the terminal
+	// and the cells the foreground color. So the glyphs are the dark modules.
+	bw := bufio.NewWriter(w)
+	for y := 0; y < size; y += 2 {
+		bw.WriteString(ansiInverse)
+		for x := 0; x < size; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				bw.WriteString("█")
+			case top:
+				bw.WriteString("▀")
+			case bottom:
+				bw.WriteString("▄")
+			default:
+				bw.WriteByte(' ')
+			}
+		}
+		bw.WriteString(ansiReset)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,58 @@
+package qr_test
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+func TestWriteTerminal(t *testing.T) {
+	info := hk.SetupInfo{
+		Code:     12344321,
+		ID:       "RFGD",
+		Flags:    hk.FlagIP | hk.FlagBTLE,
+		Category: hk.CategorySwitch,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, qr.WriteTerminal(&buf, info))
+
+	// 25 modules and the quiet zone of 4 modules on each side, two rows per
+	// line.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 17)
+
+	// Draw the half blocks as dark pixels, 8 pixels per module, and scan the
+	// result.
+	const scale = 8
+	img := image.NewGray(image.Rect(0, 0, 33*scale, 34*scale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y, line := range lines {
+		require.True(t, strings.HasPrefix(line, "\x1b[7m"))
+		require.True(t, strings.HasSuffix(line, "\x1b[0m"))
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "\x1b[7m"), "\x1b[0m")
+
+		require.Equal(t, 33, len([]rune(line)))
+		for x, r := range []rune(line) {
+			top, bottom := r == '█' || r == '▀', r == '█' || r == '▄'
+			for i, dark := range []bool{top, bottom} {
+				if dark {
+					r := image.Rect(x, 2*y+i, x+1, 2*y+i+1)
+					draw.Draw(img, image.Rectangle{r.Min.Mul(scale), r.Max.Mul(scale)}, image.Black, image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+
+	got, err := qr.ScanImage(img)
+	require.NoError(t, err)
+	assert.Equal(t, qr.Payload{SetupInfo: info}, got)
+}
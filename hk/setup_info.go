@@ -0,0 +1,189 @@
+package hk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// maxStringLength is the maximum length of the name, model and serial number
+// of an accessory as defined by the HomeKit Accessory Protocol Specification.
+const maxStringLength = 64
+
+// SetupInfo is the setup information of an accessory. The setup code, setup
+// id, setup flags, category and version make up the setup payload encoded into
+// QR codes. The other fields are optional and describe the accessory the setup
+// information belongs to.
+//
+// It marshals to JSON with the code, id, flags and category in their text
+// form:
+//
+//	{"code":"12344321","id":"RFGD","flags":"ip|btle","category":"switch","version":0}
+//
+// Its text form is a URL-encoded query string with the same keys, e.g.
+// "category=switch&code=12344321&flags=ip%7Cbtle&id=RFGD&version=0".
+type SetupInfo struct {
+	Code     Code
+	ID       ID
+	Flags    Flag
+	Category Category
+	// Version of the setup payload. Must be 0.
+	Version uint8
+
+	// Name of the accessory. Optional.
+	Name string
+	// Model of the accessory. Optional.
+	Model string
+	// SerialNumber of the accessory. Optional.
+	SerialNumber string
+	// DeviceID of the accessory. Optional.
+	DeviceID *DeviceID
+}
+
+// setupInfoJSON is the JSON representation of [SetupInfo].
+type setupInfoJSON struct {
+	Code     Code     `json:"code"`
+	ID       ID       `json:"id"`
+	Flags    Flag     `json:"flags"`
+	Category Category `json:"category"`
+	Version  uint8    `json:"version"`
+
+	Name         string    `json:"name,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	DeviceID     *DeviceID `json:"device_id,omitempty"`
+}
+
+// Validate returns all problems of the setup information at once, joined by
+// [errors.Join], or nil if it is valid. The category and unknown setup flags
+// which fit into the setup payload are not considered a problem, as they might
+// be known to Apple devices.
+func (info SetupInfo) Validate() error {
+	var errs []error
+	if !info.Code.Valid() {
+		errs = append(errs, fmt.Errorf("%w: %d is out of range", ErrInvalidCode, uint32(info.Code)))
+	}
+	if err := info.ID.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if info.Flags >= maxFlag {
+		errs = append(errs, fmt.Errorf("%w: %08b exceeds 4 bits", ErrInvalidFlag, info.Flags))
+	}
+	if info.Version != 0 {
+		errs = append(errs, fmt.Errorf("unsupported version %d", info.Version))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"name", info.Name},
+		{"model", info.Model},
+		{"serial number", info.SerialNumber},
+	} {
+		if len(field.value) > maxStringLength {
+			errs = append(errs, fmt.Errorf("%s exceeds %d bytes", field.name, maxStringLength))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (info SetupInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(setupInfoJSON(info))
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (info *SetupInfo) UnmarshalJSON(b []byte) error {
+	var v setupInfoJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*info = SetupInfo(v)
+	return nil
+}
+
+// MarshalText returns the setup information as URL-encoded query string. An
+// empty setup id and empty optional fields are omitted.
+//
+// Implements [encoding.TextMarshaler].
+func (info SetupInfo) MarshalText() ([]byte, error) {
+	code, err := info.Code.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	flags, _ := info.Flags.MarshalText()
+
+	v := url.Values{}
+	v.Set("code", string(code))
+	if info.ID != "" {
+		v.Set("id", info.ID.String())
+	}
+	v.Set("flags", string(flags))
+	v.Set("category", info.Category.Name())
+	v.Set("version", strconv.Itoa(int(info.Version)))
+	if info.Name != "" {
+		v.Set("name", info.Name)
+	}
+	if info.Model != "" {
+		v.Set("model", info.Model)
+	}
+	if info.SerialNumber != "" {
+		v.Set("serial_number", info.SerialNumber)
+	}
+	if info.DeviceID != nil {
+		v.Set("device_id", info.DeviceID.String())
+	}
+	return []byte(v.Encode()), nil
+}
+
+// UnmarshalText parses the setup information from an URL-encoded query string
+// as returned by [SetupInfo.MarshalText]. Missing keys leave the fields at
+// their zero value, unknown or repeated keys are rejected.
+//
+// Implements [encoding.TextUnmarshaler].
+func (info *SetupInfo) UnmarshalText(text []byte) error {
+	values, err := url.ParseQuery(string(text))
+	if err != nil {
+		return fmt.Errorf("parse setup info: %w", err)
+	}
+
+	var res SetupInfo
+	for key, vs := range values {
+		if len(vs) != 1 {
+			return fmt.Errorf("parse setup info: %q given %d times", key, len(vs))
+		}
+		value := vs[0]
+
+		switch key {
+		case "code":
+			err = res.Code.UnmarshalText([]byte(value))
+		case "id":
+			res.ID, err = ParseID(value)
+		case "flags":
+			res.Flags, err = ParseFlag(value)
+		case "category":
+			res.Category, err = ParseCategory(value)
+		case "version":
+			var n uint64
+			n, err = strconv.ParseUint(value, 10, 8)
+			res.Version = uint8(n)
+		case "name":
+			res.Name = value
+		case "model":
+			res.Model = value
+		case "serial_number":
+			res.SerialNumber = value
+		case "device_id":
+			var deviceID DeviceID
+			deviceID, err = ParseDeviceID(value)
+			res.DeviceID = &deviceID
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return fmt.Errorf("parse setup info: %s: %w", key, err)
+		}
+	}
+
+	*info = res
+	return nil
+}
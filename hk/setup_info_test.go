@@ -0,0 +1,144 @@
+package hk_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func TestSetupInfo_Validate(t *testing.T) {
+	deviceID := hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91}
+	info := hk.SetupInfo{
+		Code:         12344321,
+		ID:           "RFGD",
+		Flags:        hk.FlagIP | hk.FlagBTLE,
+		Category:     hk.CategorySwitch,
+		Name:         "Switch",
+		Model:        "SW1",
+		SerialNumber: "0001",
+		DeviceID:     &deviceID,
+	}
+	require.NoError(t, info.Validate())
+
+	// Categories and setup flags unknown to this package are fine.
+	info.Category = 200
+	info.Flags = 1
+	require.NoError(t, info.Validate())
+
+	err := hk.SetupInfo{
+		Code:         100000000,
+		ID:           "RF-D",
+		Flags:        hk.Flag(16),
+		Version:      1,
+		Name:         strings.Repeat("x", 65),
+		SerialNumber: strings.Repeat("x", 65),
+	}.Validate()
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, hk.ErrInvalidCode)
+	assert.ErrorIs(t, err, hk.ErrInvalidID)
+	assert.ErrorIs(t, err, hk.ErrInvalidFlag)
+	assert.Equal(t, `invalid setup code: 100000000 is out of range
+invalid setup id: invalid character '-' at position 2
+invalid setup flag: 00010000 exceeds 4 bits
+unsupported version 1
+name exceeds 64 bytes
+serial number exceeds 64 bytes`, err.Error())
+
+	err = hk.SetupInfo{}.Validate()
+	assert.ErrorIs(t, err, hk.ErrInvalidID)
+}
+
+func TestSetupInfo_MarshalJSON(t *testing.T) {
+	deviceID := hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91}
+
+	tests := []struct {
+		name string
+		info hk.SetupInfo
+		want string
+	}{
+		{
+			name: "required",
+			info: hk.SetupInfo{
+				Code:     1234,
+				ID:       "RFGD",
+				Flags:    hk.FlagIP | hk.FlagBTLE,
+				Category: hk.CategoryGarageDoorOpener,
+			},
+			want: `{"code":"00001234","id":"RFGD","flags":"ip|btle","category":"garage_door_opener","version":0}`,
+		},
+		{
+			name: "optional",
+			info: hk.SetupInfo{
+				Code:         12344321,
+				ID:           "RFGD",
+				Category:     hk.CategorySwitch,
+				Name:         "Switch",
+				Model:        "SW1",
+				SerialNumber: "0001",
+				DeviceID:     &deviceID,
+			},
+			want: `{"code":"12344321","id":"RFGD","flags":"none","category":"switch","version":0,` +
+				`"name":"Switch","model":"SW1","serial_number":"0001","device_id":"AC:1F:74:0B:5E:91"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.info)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(b))
+
+			var got hk.SetupInfo
+			require.NoError(t, json.Unmarshal(b, &got))
+			assert.Equal(t, tt.info, got)
+		})
+	}
+}
+
+func TestSetupInfo_MarshalText(t *testing.T) {
+	deviceID := hk.DeviceID{0xac, 0x1f, 0x74, 0x0b, 0x5e, 0x91}
+	info := hk.SetupInfo{
+		Code:         12344321,
+		ID:           "RFGD",
+		Flags:        hk.FlagIP | hk.FlagBTLE,
+		Category:     hk.CategorySwitch,
+		Name:         "Living Room & Kitchen",
+		SerialNumber: "0001",
+		DeviceID:     &deviceID,
+	}
+
+	text, err := info.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "category=switch&code=12344321&device_id=AC%3A1F%3A74%3A0B%3A5E%3A91&flags=ip%7Cbtle"+
+		"&id=RFGD&name=Living+Room+%26+Kitchen&serial_number=0001&version=0", string(text))
+
+	var got hk.SetupInfo
+	require.NoError(t, got.UnmarshalText(text))
+	assert.Equal(t, info, got)
+
+	require.NoError(t, got.UnmarshalText([]byte("code=1&id=rfgd&category=Garage+Door+Opener&flags=nfc,ip")))
+	assert.Equal(t, hk.SetupInfo{
+		Code:     1,
+		ID:       "RFGD",
+		Flags:    hk.FlagNFC | hk.FlagIP,
+		Category: hk.CategoryGarageDoorOpener,
+	}, got)
+
+	for _, text := range []string{
+		"code=123456789",
+		"id=RF-D",
+		"flags=wifi",
+		"category=toaster",
+		"version=256",
+		"device_id=AC:1F",
+		"code=1&code=2",
+		"color=red",
+	} {
+		assert.Error(t, got.UnmarshalText([]byte(text)), text)
+	}
+}
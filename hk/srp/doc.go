@@ -0,0 +1,7 @@
+// Package srp implements the Secure Remote Password protocol (SRP-6a) as used
+// by Apple HomeKit® accessories during pair setup. It uses the 3072-bit group
+// from RFC 5054, SHA-512 as hash function and "Pair-Setup" as username.
+//
+// Accessories should never store the plain setup code. Instead, they store a
+// salt and a verifier derived from the setup code, see [NewVerifier].
+package srp
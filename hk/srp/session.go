@@ -0,0 +1,241 @@
+package srp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// ErrInvalidProof is returned when the proof of the other party does not match,
+// which usually means that the setup code and the verifier don't belong
+// together.
+var ErrInvalidProof = fmt.Errorf("invalid proof")
+
+// ErrInvalidPublicKey is returned when the public key of the other party is not
+// acceptable.
+var ErrInvalidPublicKey = fmt.Errorf("invalid public key")
+
+// privateKeySize is the size of the ephemeral private keys in bytes.
+const privateKeySize = 32
+
+// k is the multiplier parameter k = H(N | PAD(g)).
+var k = new(big.Int).SetBytes(hash(pad(n), pad(g)))
+
+// Client is the controller side of an SRP session. It knows the setup code.
+type Client struct {
+	username string
+	password string
+
+	a, A *big.Int
+
+	m1 []byte
+	m2 []byte
+	k  []byte
+}
+
+// NewClient starts a new client session for the given setup code. The private
+// key is read from the random source r which should be cryptographically
+// secure. If r is nil, [crypto/rand.Reader] is used.
+func NewClient(code hk.Code, r io.Reader) (*Client, error) {
+	if !code.Valid() {
+		return nil, hk.ErrInvalidCode
+	}
+
+	a, err := randomPrivateKey(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(Username, password(code), a), nil
+}
+
+func newClient(username, password string, a *big.Int) *Client {
+	return &Client{
+		username: username,
+		password: password,
+		a:        a,
+		A:        new(big.Int).Exp(g, a, n),
+	}
+}
+
+// PublicKey returns the client's public key A = g^a mod N.
+func (c *Client) PublicKey() []byte {
+	return pad(c.A)
+}
+
+// Proof computes the shared session key from the salt and the server's public
+// key and returns the client's proof of it.
+func (c *Client) Proof(salt, serverPublicKey []byte) ([]byte, error) {
+	B := new(big.Int).SetBytes(serverPublicKey)
+	if new(big.Int).Mod(B, n).Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	u := computeU(c.A, B)
+	if u.Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	// S = (B - k * g^x) ^ (a + u * x) mod N
+	x := computeX(salt, c.username, c.password)
+	base := new(big.Int).Exp(g, x, n)
+	base.Mul(base, k)
+	base.Sub(B, base)
+	base.Mod(base, n)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.a)
+	S := new(big.Int).Exp(base, exp, n)
+
+	c.k = hash(pad(S))
+	c.m1 = computeM1(c.username, salt, c.A, B, c.k)
+	c.m2 = computeM2(c.A, c.m1, c.k)
+
+	return c.m1, nil
+}
+
+// VerifyProof verifies the server's proof. It must be called after
+// [Client.Proof].
+func (c *Client) VerifyProof(serverProof []byte) error {
+	if c.m2 == nil || subtle.ConstantTimeCompare(c.m2, serverProof) != 1 {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// SessionKey returns the shared session key K = H(S). It is only valid after
+// the server's proof has been verified.
+func (c *Client) SessionKey() []byte {
+	return c.k
+}
+
+// Server is the accessory side of an SRP session. It only knows the salt and
+// the verifier, not the setup code.
+type Server struct {
+	username string
+	salt     []byte
+	v        *big.Int
+
+	b, B *big.Int
+
+	m2 []byte
+	k  []byte
+}
+
+// NewServer starts a new server session for the given salt and verifier as
+// created by [NewVerifier]. The private key is read from the random source r
+// which should be cryptographically secure. If r is nil, [crypto/rand.Reader]
+// is used.
+func NewServer(salt, verifier []byte, r io.Reader) (*Server, error) {
+	b, err := randomPrivateKey(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(Username, salt, verifier, b), nil
+}
+
+func newServer(username string, salt, verifier []byte, b *big.Int) *Server {
+	v := new(big.Int).SetBytes(verifier)
+
+	// B = k * v + g^b mod N
+	B := new(big.Int).Mul(k, v)
+	B.Add(B, new(big.Int).Exp(g, b, n))
+	B.Mod(B, n)
+
+	return &Server{
+		username: username,
+		salt:     salt,
+		v:        v,
+		b:        b,
+		B:        B,
+	}
+}
+
+// Salt returns the salt the verifier was created with.
+func (s *Server) Salt() []byte {
+	return s.salt
+}
+
+// PublicKey returns the server's public key B = k * v + g^b mod N.
+func (s *Server) PublicKey() []byte {
+	return pad(s.B)
+}
+
+// VerifyProof computes the shared session key from the client's public key and
+// verifies the client's proof of it.
+func (s *Server) VerifyProof(clientPublicKey, clientProof []byte) error {
+	A := new(big.Int).SetBytes(clientPublicKey)
+	if new(big.Int).Mod(A, n).Sign() == 0 {
+		return ErrInvalidPublicKey
+	}
+
+	u := computeU(A, s.B)
+	if u.Sign() == 0 {
+		return ErrInvalidPublicKey
+	}
+
+	// S = (A * v^u) ^ b mod N
+	base := new(big.Int).Exp(s.v, u, n)
+	base.Mul(base, A)
+	base.Mod(base, n)
+	S := new(big.Int).Exp(base, s.b, n)
+
+	k := hash(pad(S))
+	m1 := computeM1(s.username, s.salt, A, s.B, k)
+	if subtle.ConstantTimeCompare(m1, clientProof) != 1 {
+		return ErrInvalidProof
+	}
+
+	s.k = k
+	s.m2 = computeM2(A, m1, k)
+
+	return nil
+}
+
+// Proof returns the server's proof. It is only valid after the client's proof
+// has been verified.
+func (s *Server) Proof() []byte {
+	return s.m2
+}
+
+// SessionKey returns the shared session key K = H(S). It is only valid after
+// the client's proof has been verified.
+func (s *Server) SessionKey() []byte {
+	return s.k
+}
+
+// computeU computes the scrambling parameter u = H(PAD(A) | PAD(B)).
+func computeU(A, B *big.Int) *big.Int {
+	return new(big.Int).SetBytes(hash(pad(A), pad(B)))
+}
+
+// computeM1 computes the client's proof
+// M1 = H(H(N) xor H(g) | H(username) | salt | A | B | K).
+func computeM1(username string, salt []byte, A, B *big.Int, k []byte) []byte {
+	hn, hg := hash(pad(n)), hash(g.Bytes())
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	return hash(hn, hash([]byte(username)), salt, pad(A), pad(B), k)
+}
+
+// computeM2 computes the server's proof M2 = H(A | M1 | K).
+func computeM2(A *big.Int, m1, k []byte) []byte {
+	return hash(pad(A), m1, k)
+}
+
+func randomPrivateKey(r io.Reader) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	buf := make([]byte, privateKeySize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("read random private key: %w", err)
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
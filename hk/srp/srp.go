@@ -0,0 +1,103 @@
+package srp
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// Username is the SRP username used by Apple HomeKit® pair setup.
+const Username = "Pair-Setup"
+
+// SaltSize is the size of the salt in bytes.
+const SaltSize = 16
+
+// The 3072-bit group from RFC 5054, Appendix A.
+var (
+	n = mustParseHex("" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E08" +
+		"8A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B" +
+		"302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9" +
+		"A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE6" +
+		"49286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8" +
+		"FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C" +
+		"180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D" +
+		"04507A33A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7D" +
+		"B3970F85A6E1E4C7ABF5AE8CDB0933D71E8C94E04A25619DCEE3D226" +
+		"1AD2EE6BF12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+		"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB3143DB5BFC" +
+		"E0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF")
+	g = big.NewInt(5)
+)
+
+// nLen is the size of the group's prime in bytes.
+var nLen = (n.BitLen() + 7) / 8
+
+// NewVerifier creates a random salt and the corresponding verifier for the
+// given setup code. The salt is read from the random source r which should be
+// cryptographically secure. If r is nil, [crypto/rand.Reader] is used. The
+// verifier is padded to the size of the group's prime (384 bytes).
+func NewVerifier(code hk.Code, r io.Reader) (salt, verifier []byte, err error) {
+	if !code.Valid() {
+		return nil, nil, hk.ErrInvalidCode
+	}
+
+	if r == nil {
+		r = rand.Reader
+	}
+
+	salt = make([]byte, SaltSize)
+	if _, err = io.ReadFull(r, salt); err != nil {
+		return nil, nil, fmt.Errorf("read random salt: %w", err)
+	}
+
+	return salt, computeVerifier(salt, Username, password(code)), nil
+}
+
+// password returns the SRP password for the setup code which is the code in
+// the Apple preferred format XXX-XX-XXX.
+func password(code hk.Code) string {
+	return code.Format()
+}
+
+// computeVerifier computes the verifier v = g^x mod N, where
+// x = H(salt | H(username | ":" | password)).
+func computeVerifier(salt []byte, username, password string) []byte {
+	x := computeX(salt, username, password)
+	return pad(new(big.Int).Exp(g, x, n))
+}
+
+// computeX computes the private key x = H(salt | H(username | ":" | password)).
+func computeX(salt []byte, username, password string) *big.Int {
+	inner := hash([]byte(username + ":" + password))
+	return new(big.Int).SetBytes(hash(salt, inner))
+}
+
+// hash returns the SHA-512 hash of the concatenation of all given byte slices.
+func hash(data ...[]byte) []byte {
+	h := sha512.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// pad returns the big-endian representation of x, padded with leading zeros to
+// the size of the group's prime.
+func pad(x *big.Int) []byte {
+	return x.FillBytes(make([]byte, nLen))
+}
+
+func mustParseHex(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic(fmt.Sprintf("srp: invalid hex number %q", s))
+	}
+	return x
+}
@@ -0,0 +1,192 @@
+package text
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	hkfont "github.com/lukasmalkmus/hkcode/hk/font"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// An Option modifies the creation of a setup code.
+type Option func(*options)
+
+type options struct {
+	policy        hk.Policy
+	font          hkfont.Provider
+	width, height int
+	strokeWidth   int
+	fontSize      float64
+	dpi           float64
+	printSize     float64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		font:        hkfont.Fallback(hkfont.Scancardium, hkfont.GoMono),
+		width:       160,
+		height:      60,
+		strokeWidth: 2,
+		fontSize:    20,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// validate returns an error if an option has an invalid value.
+func (o options) validate() error {
+	switch {
+	case o.width <= 0 || o.height <= 0:
+		return fmt.Errorf("invalid canvas size %dx%d: must be positive", o.width, o.height)
+	case o.width > MaxCanvasSize || o.height > MaxCanvasSize:
+		return fmt.Errorf("invalid canvas size %dx%d: must be at most %dx%d",
+			o.width, o.height, MaxCanvasSize, MaxCanvasSize)
+	case o.strokeWidth < 0 || 2*(o.inset()+o.strokeWidth) >= o.height || 2*(o.inset()+o.strokeWidth) >= o.width:
+		return fmt.Errorf("invalid stroke width %d: must not be negative and fit the canvas", o.strokeWidth)
+	case math.IsNaN(o.fontSize) || math.IsInf(o.fontSize, 0) || o.fontSize <= 0:
+		return fmt.Errorf("invalid font size %g: must be positive and finite", o.fontSize)
+	case math.IsNaN(o.dpi) || math.IsInf(o.dpi, 0):
+		return fmt.Errorf("invalid resolution %s DPI: must be finite", vector.FormatFloat(o.dpi))
+	case math.IsNaN(o.printSize) || math.IsInf(o.printSize, 0):
+		return fmt.Errorf("invalid print size %s mm: must be finite", vector.FormatFloat(o.printSize))
+	case o.dpi < 0:
+		return fmt.Errorf("invalid resolution %s DPI: must not be negative", vector.FormatFloat(o.dpi))
+	case o.printSize < 0:
+		return fmt.Errorf("invalid print size %s mm: must not be negative", vector.FormatFloat(o.printSize))
+	case o.printSize > 0 && o.dpi > 0:
+		// The canvas is scaled to the print size, keeping its aspect ratio.
+		width := o.printSize / resolution.MMPerInch * o.dpi
+		if width > MaxCanvasSize || width*float64(o.height)/float64(o.width) > MaxCanvasSize {
+			return fmt.Errorf("invalid print size %s mm at %s DPI: canvas must be at most %dx%d pixels",
+				vector.FormatFloat(o.printSize), vector.FormatFloat(o.dpi), MaxCanvasSize, MaxCanvasSize)
+		}
+	}
+	return nil
+}
+
+// scaled returns the options with the canvas, border and font scaled to the
+// print size, if it and a resolution are given.
+func (o options) scaled() options {
+	if o.printSize == 0 || o.dpi == 0 {
+		return o
+	}
+	width := resolution.Pixels(o.printSize, o.dpi)
+	k := float64(width) / float64(o.width)
+	o.width = width
+	o.height = int(math.Round(float64(o.height) * k))
+	if o.strokeWidth > 0 {
+		o.strokeWidth = int(math.Max(1, math.Round(float64(o.strokeWidth)*k)))
+	}
+	o.fontSize *= k
+	return o
+}
+
+// printWidth returns the width of the code on paper in millimetres or 0 if it
+// is unknown.
+func (o options) printWidth() float64 {
+	switch {
+	case o.printSize > 0:
+		return o.printSize
+	case o.dpi > 0:
+		return resolution.MM(float64(o.width), o.dpi)
+	}
+	return 0
+}
+
+// checkFont returns an error wrapping [ErrTooSmall] if the code would be
+// printed with a font smaller than [MinFontSize] or, if it is a raster image,
+// [MinFontPixels]. Codes without a print size are never too small.
+func (o options) checkFont(raster bool) error {
+	if raster && o.printSize > 0 && o.dpi == 0 {
+		return fmt.Errorf("print size %s mm needs a resolution", vector.FormatFloat(o.printSize))
+	}
+
+	width := o.printWidth()
+	if width == 0 {
+		return nil
+	}
+	if size := o.fontSize * width / float64(o.width); size < MinFontSize {
+		return fmt.Errorf("%w: font is %s mm, need at least %s mm",
+			ErrTooSmall, vector.FormatFloat(size), vector.FormatFloat(MinFontSize))
+	}
+	if raster && o.dpi > 0 && o.fontSize < MinFontPixels {
+		return fmt.Errorf("%w: font is %s pixels at %s DPI, need at least %d pixels",
+			ErrTooSmall, vector.FormatFloat(o.fontSize), vector.FormatFloat(o.dpi), MinFontPixels)
+	}
+	return nil
+}
+
+// inset is the distance of the border from the canvas edges in pixels.
+func (o options) inset() int {
+	return o.height / 12
+}
+
+// WithPolicy rejects setup codes which do not comply with the given policy.
+// By default, only invalid setup codes are rejected. Use [hk.PolicyStrict] to
+// reject the trivial codes forbidden by the HomeKit Accessory Protocol
+// Specification.
+func WithPolicy(policy hk.Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithFont sets the font the setup code is set in. By default, Scancardium is
+// used where available and Go Mono elsewhere, see [hkfont.Fallback].
+func WithFont(font hkfont.Provider) Option {
+	return func(o *options) {
+		o.font = font
+	}
+}
+
+// WithCanvasSize sets the size of the image in pixels. The border is inset by
+// a twelfth of the height. By default, the canvas is 160x60 pixels. Canvases
+// larger than [MaxCanvasSize] are rejected.
+func WithCanvasSize(width, height int) Option {
+	return func(o *options) {
+		o.width, o.height = width, height
+	}
+}
+
+// WithStrokeWidth sets the width of the border in pixels. By default, it is 2
+// pixels wide. A width of 0 removes the border.
+func WithStrokeWidth(width int) Option {
+	return func(o *options) {
+		o.strokeWidth = width
+	}
+}
+
+// WithDPI sets the resolution the code is printed at in dots per inch. It is
+// stored in the files written by [WritePNG] and gives the code a print size,
+// see [WithPrintSize].
+func WithDPI(dpi float64) Option {
+	return func(o *options) {
+		o.dpi = dpi
+	}
+}
+
+// WithPrintSize sets the width of the code on paper in millimetres. Raster
+// images need a resolution, see [WithDPI]. Their canvas, border and font are
+// scaled to the width in pixels following from both, keeping the aspect ratio
+// of the canvas. SVG documents are given the print size as their physical
+// size.
+//
+// Codes with a print size are rejected with [ErrTooSmall] if their font would
+// be too small to be read reliably.
+func WithPrintSize(mm float64) Option {
+	return func(o *options) {
+		o.printSize = mm
+	}
+}
+
+// WithFontSize sets the size of the font in pixels per em. By default, it is
+// 20 pixels.
+func WithFontSize(size float64) Option {
+	return func(o *options) {
+		o.fontSize = size
+	}
+}
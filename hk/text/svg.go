@@ -0,0 +1,78 @@
+package text
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// WriteSVG writes a text based Apple HomeKit® setup code as SVG to w. It is the
+// vector equivalent of [CreateCode]: The digits are glyph outlines, so no fonts
+// are needed to display it.
+func WriteSVG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	o := newOptions(opts)
+
+	if err := o.validate(); err != nil {
+		return err
+	}
+	o = o.scaled()
+	if err := o.checkFont(false); err != nil {
+		return err
+	}
+
+	setupCode := info.Code
+	if !setupCode.Valid() {
+		return fmt.Errorf("%w: %d is out of range", hk.ErrInvalidCode, uint32(setupCode))
+	} else if err := o.policy.Check(setupCode); err != nil {
+		return err
+	}
+
+	otf, err := o.font.Font()
+	if err != nil {
+		return fmt.Errorf("load font: %w", err)
+	}
+
+	var (
+		width, height = float64(o.width), float64(o.height)
+		inset         = float64(o.inset())
+		stroke        = float64(o.strokeWidth)
+		fontSize      = o.fontSize
+	)
+
+	var background vector.Path
+	background.Rect(0, 0, width, height)
+
+	// The border of the raster image, stroked along its center. Its right and
+	// bottom edge are one pixel further out.
+	var border vector.Path
+	border.Rect(inset+stroke/2, inset+stroke/2, width-2*inset+1-stroke, height-2*inset+1-stroke)
+
+	// Center the code inside the image.
+	formattedCode := setupCode.Format()
+	textWidth, err := vector.TextWidth(otf, formattedCode, fontSize)
+	if err != nil {
+		return fmt.Errorf("measure code: %w", err)
+	}
+	ascent, err := vector.Ascent(otf, fontSize)
+	if err != nil {
+		return fmt.Errorf("get font metrics: %w", err)
+	}
+	glyphs, err := vector.Text(otf, formattedCode, fontSize, (width-textWidth)/2, (height+ascent)/2)
+	if err != nil {
+		return fmt.Errorf("create glyph outlines: %w", err)
+	}
+
+	shapes := []vector.Shape{{Path: background, Fill: color.White}}
+	if stroke > 0 {
+		shapes = append(shapes, vector.Shape{Path: border, Stroke: color.Black, StrokeWidth: stroke})
+	}
+	shapes = append(shapes, vector.Shape{Path: glyphs, Fill: color.Black})
+
+	if pw := o.printWidth(); pw > 0 {
+		return vector.WritePrintSVG(w, width, height, pw/width, shapes...)
+	}
+	return vector.WriteSVG(w, width, height, shapes...)
+}
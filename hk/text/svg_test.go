@@ -0,0 +1,66 @@
+package text_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/text"
+)
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, text.WriteSVG(&buf, hk.SetupInfo{Code: 12344321}))
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Width   string   `xml:"width,attr"`
+		Height  string   `xml:"height,attr"`
+		Paths   []struct {
+			D      string `xml:"d,attr"`
+			Fill   string `xml:"fill,attr"`
+			Stroke string `xml:"stroke,attr"`
+		} `xml:"path"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "160", doc.Width)
+	assert.Equal(t, "60", doc.Height)
+	require.Len(t, doc.Paths, 3)
+	assert.Equal(t, "#ffffff", doc.Paths[0].Fill)
+	assert.Equal(t, "#000000", doc.Paths[1].Stroke)
+	assert.Equal(t, "#000000", doc.Paths[2].Fill)
+
+	// The glyph outlines consist of one closed sub-path per digit at least.
+	assert.GreaterOrEqual(t, bytes.Count([]byte(doc.Paths[2].D), []byte("Z")), 8)
+}
+
+func TestWriteSVG_Policy(t *testing.T) {
+	err := text.WriteSVG(&bytes.Buffer{}, hk.SetupInfo{Code: 12345678}, text.WithPolicy(hk.PolicyStrict))
+	require.ErrorIs(t, err, hk.ErrTrivialCode)
+}
+
+func TestWriteSVG_Options(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, text.WriteSVG(&buf, hk.SetupInfo{Code: 12344321},
+		text.WithCanvasSize(640, 240),
+		text.WithStrokeWidth(8),
+	))
+
+	assert.Contains(t, buf.String(), `width="640" height="240"`)
+	// The stroke is centered on the border of the raster image.
+	assert.Contains(t, buf.String(), `<path d="M24 24L617 24L617 217L24 217Z" fill="none" stroke="#000000" stroke-width="8"/>`)
+}
+
+func TestWriteSVG_PrintSize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, text.WriteSVG(&buf, hk.SetupInfo{Code: 12344321}, text.WithPrintSize(40)))
+	assert.Contains(t, buf.String(), `width="40mm" height="15mm" viewBox="0 0 160 60"`)
+
+	err := text.WriteSVG(&buf, hk.SetupInfo{Code: 12344321}, text.WithPrintSize(10))
+	assert.ErrorIs(t, err, text.ErrTooSmall)
+}
@@ -5,35 +5,69 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 
 	"github.com/lukasmalkmus/hkcode/hk"
-	embeddedFont "github.com/lukasmalkmus/hkcode/internal/assets/font"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
 )
 
-// CreateCode creates a text based Apple HomeKit® setup code.
-func CreateCode(setupCode hk.Code) (image.Image, error) {
+// ErrTooSmall is returned when a code with a print size would have a font too
+// small to be read reliably, see [WithPrintSize].
+var ErrTooSmall = fmt.Errorf("code too small to read")
+
+const (
+	// MinFontSize is the minimum font size of a printed code in millimetres
+	// per em, about 4 points.
+	MinFontSize = 1.5
+	// MinFontPixels is the minimum font size of a printed raster image in
+	// pixels per em.
+	MinFontPixels = 10
+	// MaxCanvasSize is the maximum width and height of the canvas in pixels.
+	// Larger canvases would need too much memory.
+	MaxCanvasSize = 8192
+)
+
+// CreateCode creates a text based Apple HomeKit® setup code for the given
+// setup information. Only the setup code is used and it is checked against the
+// policy set by [WithPolicy]. The other fields are neither used nor validated.
+// To print it, give it a print size with [WithPrintSize] and [WithDPI].
+func CreateCode(info hk.SetupInfo, opts ...Option) (image.Image, error) {
+	o := newOptions(opts)
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	o = o.scaled()
+	if err := o.checkFont(true); err != nil {
+		return nil, err
+	}
+
+	setupCode := info.Code
 	if !setupCode.Valid() {
-		return nil, hk.ErrInvalidCode
+		return nil, fmt.Errorf("%w: %d is out of range", hk.ErrInvalidCode, uint32(setupCode))
+	} else if err := o.policy.Check(setupCode); err != nil {
+		return nil, err
 	}
 
-	// 150x50px with 10px padding and white background.
-	img := image.NewRGBA(image.Rect(0, 0, 160, 60))
+	// White background.
+	img := image.NewRGBA(image.Rect(0, 0, o.width, o.height))
 	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
 
-	// Place the rectangle in the middle of the image, 2px border.
-	drawRectangle(img, color.Black, image.Rect(5, 5, 155, 55), 2)
+	// Place the rectangle in the middle of the image.
+	inset := o.inset()
+	drawRectangle(img, color.Black, image.Rect(inset, inset, o.width-inset, o.height-inset), uint(o.strokeWidth))
 
-	otf, err := embeddedFont.Scancardium()
+	otf, err := o.font.Font()
 	if err != nil {
 		return nil, fmt.Errorf("load font: %w", err)
 	}
 
 	face, err := opentype.NewFace(otf, &opentype.FaceOptions{
-		Size: 20,
+		Size: o.fontSize,
 		DPI:  72,
 	})
 	if err != nil {
@@ -59,6 +93,17 @@ func CreateCode(setupCode hk.Code) (image.Image, error) {
 	return img, nil
 }
 
+// WritePNG writes a text based Apple HomeKit® setup code as created by
+// [CreateCode] as PNG to w. The resolution set by [WithDPI] is stored in it, so
+// it is printed at its print size.
+func WritePNG(w io.Writer, info hk.SetupInfo, opts ...Option) error {
+	img, err := CreateCode(info, opts...)
+	if err != nil {
+		return err
+	}
+	return resolution.EncodePNG(w, img, newOptions(opts).dpi)
+}
+
 func drawRectangle(img draw.Image, color color.Color, rect image.Rectangle, stroke uint) {
 	for i := 0; i < int(stroke); i++ {
 		for i := rect.Min.X; i < rect.Max.X; i++ {
@@ -1,20 +1,143 @@
 package text_test
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/lukasmalkmus/hkcode/hk"
+	hkfont "github.com/lukasmalkmus/hkcode/hk/font"
 	"github.com/lukasmalkmus/hkcode/hk/text"
+	"github.com/lukasmalkmus/hkcode/internal/resolution"
 	"github.com/lukasmalkmus/hkcode/internal/testdata"
 	"github.com/lukasmalkmus/hkcode/internal/testutil"
 )
 
 func TestCreateCode(t *testing.T) {
-	golden := testdata.GetGoldenTextImage(t)
+	for _, name := range hkfont.Names() {
+		t.Run(name, func(t *testing.T) {
+			font, _ := hkfont.Lookup(name)
+			if _, err := font.Font(); errors.Is(err, hkfont.ErrUnavailable) {
+				t.Skip(err)
+			}
 
-	img, err := text.CreateCode(12344321)
+			golden := testdata.GetGoldenTextImage(t, name)
+
+			img, err := text.CreateCode(hk.SetupInfo{Code: 12344321}, text.WithFont(font))
+			require.NoError(t, err)
+
+			testutil.AssertEqualImage(t, golden, img)
+		})
+	}
+}
+
+func TestCreateCode_Policy(t *testing.T) {
+	_, err := text.CreateCode(hk.SetupInfo{Code: 12345678}, text.WithPolicy(hk.PolicyStrict))
+	require.ErrorIs(t, err, hk.ErrTrivialCode)
+}
+
+func TestCreateCode_Invalid(t *testing.T) {
+	_, err := text.CreateCode(hk.SetupInfo{Code: 100000000})
+	require.ErrorIs(t, err, hk.ErrInvalidCode)
+}
+
+func TestCreateCode_Options(t *testing.T) {
+	img, err := text.CreateCode(hk.SetupInfo{Code: 12344321},
+		text.WithCanvasSize(640, 240),
+		text.WithStrokeWidth(8),
+		text.WithFontSize(80),
+	)
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 640, 240), img.Bounds())
+
+	// The border is inset by 20 pixels and 8 pixels wide.
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.At(19, 120))
+	assert.Equal(t, color.RGBA{A: 0xff}, img.At(20, 120))
+	assert.Equal(t, color.RGBA{A: 0xff}, img.At(27, 120))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.At(28, 120))
+
+	img, err = text.CreateCode(hk.SetupInfo{Code: 12344321}, text.WithStrokeWidth(0))
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, img.At(5, 30))
+}
+
+func TestCreateCode_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []text.Option
+		wantErr string
+	}{
+		{"empty canvas", []text.Option{text.WithCanvasSize(0, 60)}, "invalid canvas size 0x60: must be positive"},
+		{"huge canvas", []text.Option{text.WithCanvasSize(2000000, 60)}, "invalid canvas size 2000000x60: must be at most 8192x8192"},
+		{"stroke width", []text.Option{text.WithStrokeWidth(30)}, "invalid stroke width 30: must not be negative and fit the canvas"},
+		{"font size", []text.Option{text.WithFontSize(-1)}, "invalid font size -1: must be positive and finite"},
+		{"NaN font size", []text.Option{text.WithFontSize(math.NaN())}, "invalid font size NaN: must be positive and finite"},
+		{"infinite font size", []text.Option{text.WithFontSize(math.Inf(1))}, "invalid font size +Inf: must be positive and finite"},
+		{"NaN resolution", []text.Option{text.WithDPI(math.NaN())}, "invalid resolution NaN DPI: must be finite"},
+		{"infinite resolution", []text.Option{text.WithDPI(math.Inf(1))}, "invalid resolution +Inf DPI: must be finite"},
+		{"NaN print size", []text.Option{text.WithPrintSize(math.NaN())}, "invalid print size NaN mm: must be finite"},
+		{
+			"infinite print size", []text.Option{text.WithPrintSize(math.Inf(1)), text.WithDPI(300)},
+			"invalid print size +Inf mm: must be finite",
+		},
+		{
+			"huge print size", []text.Option{text.WithPrintSize(1000), text.WithDPI(1200)},
+			"invalid print size 1000 mm at 1200 DPI: canvas must be at most 8192x8192 pixels",
+		},
+		{
+			// The canvas is scaled to 2362x11811 pixels.
+			"tall canvas", []text.Option{text.WithCanvasSize(60, 300), text.WithPrintSize(100), text.WithDPI(600)},
+			"invalid print size 100 mm at 600 DPI: canvas must be at most 8192x8192 pixels",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := text.CreateCode(hk.SetupInfo{Code: 12344321}, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWritePNG_PrintSize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, text.WritePNG(&buf, hk.SetupInfo{Code: 12344321}, text.WithPrintSize(40), text.WithDPI(300)))
+
+	dpi, ok := resolution.PNGDPI(buf.Bytes())
+	require.True(t, ok)
+	assert.InDelta(t, 300, dpi, 0.01)
+
+	// The canvas keeps its aspect ratio and the border is scaled along.
+	img, err := png.Decode(&buf)
 	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 472, 177), img.Bounds())
+	assertGray(t, 0xff, img.At(13, 88))
+	assertGray(t, 0, img.At(14, 88))
+	assertGray(t, 0, img.At(19, 88))
+	assertGray(t, 0xff, img.At(20, 88))
+}
+
+func TestCreateCode_TooSmall(t *testing.T) {
+	_, err := text.CreateCode(hk.SetupInfo{Code: 12344321}, text.WithPrintSize(10), text.WithDPI(600))
+	require.ErrorIs(t, err, text.ErrTooSmall)
+	assert.EqualError(t, err, "code too small to read: font is 1.25 mm, need at least 1.5 mm")
+
+	_, err = text.CreateCode(hk.SetupInfo{Code: 12344321}, text.WithPrintSize(20), text.WithDPI(72))
+	require.ErrorIs(t, err, text.ErrTooSmall)
+	assert.EqualError(t, err, "code too small to read: font is 7.125 pixels at 72 DPI, need at least 10 pixels")
+
+	_, err = text.CreateCode(hk.SetupInfo{Code: 12344321}, text.WithPrintSize(20))
+	assert.EqualError(t, err, "print size 20 mm needs a resolution")
+}
+
+func assertGray(t *testing.T, want uint8, got color.Color) {
+	t.Helper()
 
-	testutil.AssertEqualImage(t, golden, img)
+	assert.Equal(t, color.GrayModel.Convert(color.Gray{Y: want}), color.GrayModel.Convert(got))
 }
@@ -0,0 +1,12 @@
+// Package tlv8 implements the TLV8 (type-length-value) encoding used by many
+// Apple HomeKit® data structures like pairing messages, setup payload
+// extensions and Bluetooth LE data.
+//
+// Each item consists of a one byte type, a one byte length and up to 255 bytes
+// of value. Longer values are split into fragments: consecutive items of the
+// same type which are concatenated when decoding. Consecutive items of the same
+// type which are not fragments must be delimited by a separator item.
+//
+// Items can be encoded and decoded directly using [Encode] and [Decode] or be
+// mapped to structs using [Marshal] and [Unmarshal].
+package tlv8
@@ -0,0 +1,211 @@
+package tlv8
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the TLV8 encoding of v, which must be a struct or a pointer
+// to one. Fields are encoded in order. Only exported fields with a "tlv8" tag
+// are encoded. The tag holds the item type, optionally followed by
+// ",omitempty" to omit the item if the field has its zero value:
+//
+//	type message struct {
+//		State     uint8  `tlv8:"6"`
+//		Method    *uint8 `tlv8:"0"`
+//		PublicKey []byte `tlv8:"3,omitempty"`
+//	}
+//
+// Supported field types are []byte, string, bool, unsigned integers (encoded
+// little-endian with the size of the Go type) and structs (encoded as nested
+// TLV8 data). Pointers to these types are omitted if nil.
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("tlv8: Marshal(nil)")
+	}
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("tlv8: Marshal(nil %s)", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tlv8: Marshal(non-struct %s)", rv.Type())
+	}
+
+	items, err := marshalStruct(rv)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(items), nil
+}
+
+func marshalStruct(rv reflect.Value) ([]Item, error) {
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		value, err := marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("tlv8: field %s: %w", f.name, err)
+		}
+		items = append(items, Item{Type: f.typ, Value: value})
+	}
+	return items, nil
+}
+
+func marshalValue(rv reflect.Value) ([]byte, error) {
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	case reflect.String:
+		return []byte(rv.String()), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b := binary.LittleEndian.AppendUint64(nil, rv.Uint())
+		return b[:rv.Type().Size()], nil
+	case reflect.Struct:
+		items, err := marshalStruct(rv)
+		if err != nil {
+			return nil, err
+		}
+		return Encode(items), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+// Unmarshal decodes the TLV8 data into v, which must be a non-nil pointer to a
+// struct. See [Marshal] for the supported struct fields. Items without a
+// matching field are ignored. If an item occurs more than once, only the first
+// one is used. Pointer fields are only allocated if their item is present.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("tlv8: Unmarshal(non-pointer or non-struct %T)", v)
+	}
+
+	items, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	return unmarshalStruct(items, rv.Elem())
+}
+
+func unmarshalStruct(items []Item, rv reflect.Value) error {
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		for _, item := range items {
+			if item.Type != f.typ {
+				continue
+			}
+
+			fv := rv.Field(f.index)
+			if fv.Kind() == reflect.Pointer {
+				fv.Set(reflect.New(fv.Type().Elem()))
+				fv = fv.Elem()
+			}
+			if err := unmarshalValue(item.Value, fv); err != nil {
+				return fmt.Errorf("tlv8: field %s: %w", f.name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(value []byte, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes(append([]byte{}, value...))
+			return nil
+		}
+	case reflect.String:
+		rv.SetString(string(value))
+		return nil
+	case reflect.Bool:
+		if len(value) != 1 || value[0] > 1 {
+			return fmt.Errorf("invalid bool value %x", value)
+		}
+		rv.SetBool(value[0] == 1)
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if len(value) == 0 || uintptr(len(value)) > rv.Type().Size() {
+			return fmt.Errorf("invalid length %d for %s", len(value), rv.Type())
+		}
+		var buf [8]byte
+		copy(buf[:], value)
+		rv.SetUint(binary.LittleEndian.Uint64(buf[:]))
+		return nil
+	case reflect.Struct:
+		items, err := Decode(value)
+		if err != nil {
+			return err
+		}
+		return unmarshalStruct(items, rv)
+	}
+	return fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+type field struct {
+	name      string
+	index     int
+	typ       byte
+	omitEmpty bool
+}
+
+// structFields returns the tagged fields of the struct type t.
+func structFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("tlv8")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		typ, err := strconv.ParseUint(name, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("tlv8: field %s: invalid type %q in tag", sf.Name, name)
+		} else if byte(typ) == TypeSeparator {
+			return nil, fmt.Errorf("tlv8: field %s: type %d is reserved for separators", sf.Name, typ)
+		}
+
+		fields = append(fields, field{
+			name:      sf.Name,
+			index:     i,
+			typ:       byte(typ),
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	return fields, nil
+}
@@ -0,0 +1,161 @@
+package tlv8_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk/tlv8"
+)
+
+type nested struct {
+	ID   string `tlv8:"1"`
+	Keep bool   `tlv8:"2"`
+}
+
+type message struct {
+	State   uint8   `tlv8:"6"`
+	Method  *uint8  `tlv8:"0"`
+	Salt    []byte  `tlv8:"2,omitempty"`
+	Flags   uint32  `tlv8:"0x13,omitempty"`
+	Name    string  `tlv8:"1,omitempty"`
+	Sub     *nested `tlv8:"5"`
+	Ignored string
+	ignored string `tlv8:"9"`
+}
+
+func TestMarshal(t *testing.T) {
+	method := uint8(0)
+
+	tests := []struct {
+		name string
+		v    any
+		want []byte
+	}{
+		{
+			name: "zero value",
+			v:    message{},
+			want: []byte{6, 1, 0},
+		},
+		{
+			name: "pointer",
+			v:    &message{State: 1, Method: &method},
+			want: []byte{6, 1, 1, 0, 1, 0},
+		},
+		{
+			name: "all fields",
+			v: message{
+				State:   2,
+				Salt:    []byte{0xaa, 0xbb},
+				Flags:   0x01020304,
+				Name:    "hk",
+				Sub:     &nested{ID: "x", Keep: true},
+				Ignored: "ignored",
+			},
+			want: []byte{
+				6, 1, 2,
+				2, 2, 0xaa, 0xbb,
+				0x13, 4, 4, 3, 2, 1,
+				1, 2, 'h', 'k',
+				5, 6, 1, 1, 'x', 2, 1, 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tlv8.Marshal(tt.v)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarshal_Error(t *testing.T) {
+	for _, v := range []any{
+		nil,
+		(*message)(nil),
+		"string",
+		struct {
+			A int `tlv8:"1"`
+		}{},
+		struct {
+			A string `tlv8:"a"`
+		}{},
+		struct {
+			A string `tlv8:"255"`
+		}{},
+	} {
+		_, err := tlv8.Marshal(v)
+		assert.Error(t, err, "%#v", v)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var got message
+	err := tlv8.Unmarshal([]byte{
+		6, 1, 3,
+		0, 1, 0,
+		0x13, 2, 0x02, 0x01,
+		1, 2, 'h', 'k',
+		0xff, 0,
+		1, 2, 'x', 'x', // Only the first item is used.
+		5, 3, 2, 1, 0,
+		0x42, 0, // Unknown items are ignored.
+	}, &got)
+	require.NoError(t, err)
+
+	method := uint8(0)
+	assert.Equal(t, message{
+		State:  3,
+		Method: &method,
+		Flags:  0x0102,
+		Name:   "hk",
+		Sub:    &nested{Keep: false},
+	}, got)
+}
+
+func TestUnmarshal_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		v    any
+	}{
+		{"non-pointer", []byte{}, message{}},
+		{"nil pointer", []byte{}, (*message)(nil)},
+		{"truncated", []byte{6, 2, 1}, &message{}},
+		{"empty uint", []byte{6, 0}, &message{}},
+		{"uint too long", []byte{6, 2, 1, 0}, &message{}},
+		{"invalid bool", []byte{5, 3, 2, 1, 2}, &message{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tlv8.Unmarshal(tt.data, tt.v))
+		})
+	}
+}
+
+func FuzzMarshal(f *testing.F) {
+	f.Add(uint8(1), []byte{}, uint32(0), "", false)
+	f.Add(uint8(6), make([]byte, 600), uint32(0xffffffff), "x", true)
+
+	f.Fuzz(func(t *testing.T, state uint8, salt []byte, flags uint32, name string, keep bool) {
+		want := message{
+			State: state,
+			Salt:  salt,
+			Flags: flags,
+			Name:  name,
+			Sub:   &nested{ID: name, Keep: keep},
+		}
+		if len(want.Salt) == 0 {
+			want.Salt = nil
+		}
+
+		b, err := tlv8.Marshal(want)
+		require.NoError(t, err)
+
+		var got message
+		require.NoError(t, tlv8.Unmarshal(b, &got))
+		require.Equal(t, want, got)
+	})
+}
@@ -0,0 +1,80 @@
+package tlv8
+
+import "fmt"
+
+// ErrTruncated is returned when TLV8 data ends in the middle of an item.
+var ErrTruncated = fmt.Errorf("tlv8: truncated item")
+
+// TypeSeparator is the type of the zero length item separating consecutive
+// items of the same type which are not fragments of a single item.
+const TypeSeparator byte = 0xff
+
+// maxFragmentLen is the maximum length of a single fragment.
+const maxFragmentLen = 255
+
+// Item is a single TLV8 item.
+type Item struct {
+	Type  byte
+	Value []byte
+}
+
+// Encode encodes the items in order. Values longer than 255 bytes are split into
+// fragments. If two consecutive items have the same type, a separator is
+// inserted between them.
+func Encode(items []Item) []byte {
+	var b []byte
+	for i, item := range items {
+		if i > 0 && items[i-1].Type == item.Type && item.Type != TypeSeparator {
+			b = append(b, TypeSeparator, 0)
+		}
+		b = appendItem(b, item)
+	}
+	return b
+}
+
+func appendItem(b []byte, item Item) []byte {
+	value := item.Value
+	for {
+		n := len(value)
+		if n > maxFragmentLen {
+			n = maxFragmentLen
+		}
+		b = append(b, item.Type, byte(n))
+		b = append(b, value[:n]...)
+		if value = value[n:]; len(value) == 0 {
+			return b
+		}
+	}
+}
+
+// Decode decodes the items of data. Fragments are concatenated. Separators are
+// not returned.
+func Decode(data []byte) ([]Item, error) {
+	var (
+		items     []Item
+		fragments bool
+	)
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, ErrTruncated
+		}
+		typ, n := data[0], int(data[1])
+		if len(data) < 2+n {
+			return nil, fmt.Errorf("%w: type %d needs %d bytes, got %d", ErrTruncated, typ, n, len(data)-2)
+		}
+		value := data[2 : 2+n]
+		data = data[2+n:]
+
+		switch {
+		case typ == TypeSeparator:
+			fragments = false
+		case fragments && items[len(items)-1].Type == typ:
+			last := &items[len(items)-1]
+			last.Value = append(last.Value, value...)
+		default:
+			items = append(items, Item{Type: typ, Value: append([]byte{}, value...)})
+			fragments = true
+		}
+	}
+	return items, nil
+}
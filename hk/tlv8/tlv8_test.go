@@ -0,0 +1,112 @@
+package tlv8_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk/tlv8"
+)
+
+func TestEncode(t *testing.T) {
+	long := bytes.Repeat([]byte{0xab}, 300)
+
+	tests := []struct {
+		name  string
+		items []tlv8.Item
+		want  []byte
+	}{
+		{
+			name:  "single item",
+			items: []tlv8.Item{{Type: 6, Value: []byte{1}}},
+			want:  []byte{6, 1, 1},
+		},
+		{
+			name:  "empty value",
+			items: []tlv8.Item{{Type: 6}},
+			want:  []byte{6, 0},
+		},
+		{
+			name:  "fragmented value",
+			items: []tlv8.Item{{Type: 3, Value: long}},
+			want: append(append(append([]byte{3, 255}, long[:255]...),
+				3, 45), long[255:]...),
+		},
+		{
+			name:  "exactly 255 bytes",
+			items: []tlv8.Item{{Type: 3, Value: long[:255]}},
+			want:  append([]byte{3, 255}, long[:255]...),
+		},
+		{
+			name: "separator between items of the same type",
+			items: []tlv8.Item{
+				{Type: 1, Value: []byte("a")},
+				{Type: 1, Value: []byte("b")},
+				{Type: 2, Value: []byte("c")},
+			},
+			want: []byte{1, 1, 'a', 0xff, 0, 1, 1, 'b', 2, 1, 'c'},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tlv8.Encode(tt.items)
+			assert.Equal(t, tt.want, got)
+
+			items, err := tlv8.Decode(got)
+			require.NoError(t, err)
+
+			require.Len(t, items, len(tt.items))
+			for i := range items {
+				assert.Equal(t, tt.items[i].Type, items[i].Type)
+				assert.Equal(t, len(tt.items[i].Value), len(items[i].Value))
+				assert.True(t, bytes.Equal(tt.items[i].Value, items[i].Value))
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	items, err := tlv8.Decode([]byte{
+		6, 1, 2, // State
+		3, 2, 'a', 'b', 3, 1, 'c', // Fragmented public key
+		0xff, 0, // Separator
+		3, 1, 'd', // Second public key
+		2, 0, // Empty salt
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []tlv8.Item{
+		{Type: 6, Value: []byte{2}},
+		{Type: 3, Value: []byte("abc")},
+		{Type: 3, Value: []byte("d")},
+		{Type: 2, Value: []byte{}},
+	}, items)
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	for _, data := range [][]byte{{6}, {6, 2, 1}, {6, 1, 1, 3}} {
+		_, err := tlv8.Decode(data)
+		assert.ErrorIs(t, err, tlv8.ErrTruncated, "%v", data)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{6, 1, 2, 3, 2, 'a', 'b', 3, 1, 'c', 0xff, 0, 3, 1, 'd'})
+	f.Add(append([]byte{5, 255}, make([]byte, 255)...))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		items, err := tlv8.Decode(data)
+		if err != nil {
+			return
+		}
+
+		// Re-encoding and decoding again yields the same items. The encoding
+		// itself might differ as fragments can be split differently.
+		got, err := tlv8.Decode(tlv8.Encode(items))
+		require.NoError(t, err)
+		require.Equal(t, items, got)
+	})
+}
@@ -0,0 +1,84 @@
+package assets
+
+import (
+	"image/color"
+
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// BoxWidth and BoxHeight are the size of the setup code box template in
+// pixels.
+const (
+	BoxWidth  = 400
+	BoxHeight = 539
+)
+
+// boxColor is the color of the frame and the logo of the box template.
+var boxColor = color.RGBA{R: 0x11, G: 0x11, B: 0x0e, A: 0xff}
+
+// BoxFrame returns the path of the rounded frame of the setup code box
+// template, along the center of its stroke.
+func BoxFrame() vector.Path {
+	var frame vector.Path
+	frame.RoundedRect(6.5, 6.5, BoxWidth-13, BoxHeight-13, 48.5)
+	return frame
+}
+
+// BoxShapes returns the vector shapes of the setup code box template: The
+// rounded frame and the Apple HomeKit® logo in the top left corner, drawn in
+// the color c. If c is nil, they match the raster image returned by [Box].
+func BoxShapes(c color.Color) []vector.Shape {
+	if c == nil {
+		c = boxColor
+	}
+
+	frame := BoxFrame()
+
+	// The outer house: Roof and walls which merge into the chimney.
+	var outer vector.Path
+	outer.MoveTo(28.5, 87.5)
+	outer.LineTo(91.5, 40.5)
+	outer.LineTo(154.5, 87.5)
+	outer.MoveTo(45.5, 78)
+	outer.LineTo(45.5, 140.5)
+	outer.QuadTo(45.5, 149.5, 54.5, 149.5)
+	outer.LineTo(128.5, 149.5)
+	outer.QuadTo(137.5, 149.5, 137.5, 140.5)
+	outer.LineTo(137.5, 70)
+
+	var chimney vector.Path
+	chimney.MoveTo(128, 75)
+	chimney.LineTo(128, 55)
+	chimney.QuadTo(128, 51, 132, 51)
+	chimney.LineTo(139, 51)
+	chimney.QuadTo(143, 51, 143, 55)
+	chimney.LineTo(143, 75)
+	chimney.Close()
+
+	var middle vector.Path
+	middle.MoveTo(65.5, 125.5)
+	middle.LineTo(65.5, 87)
+	middle.LineTo(91.5, 67)
+	middle.LineTo(117.5, 87)
+	middle.LineTo(117.5, 125.5)
+	middle.QuadTo(117.5, 128.5, 114.5, 128.5)
+	middle.LineTo(68.5, 128.5)
+	middle.QuadTo(65.5, 128.5, 65.5, 125.5)
+	middle.Close()
+
+	var inner vector.Path
+	inner.MoveTo(91.5, 86.5)
+	inner.LineTo(102, 95)
+	inner.LineTo(102, 112.5)
+	inner.LineTo(81, 112.5)
+	inner.LineTo(81, 95)
+	inner.Close()
+
+	return []vector.Shape{
+		{Path: frame, Stroke: c, StrokeWidth: 13},
+		{Path: outer, Stroke: c, StrokeWidth: 11},
+		{Path: chimney, Fill: c},
+		{Path: middle, Stroke: c, StrokeWidth: 11},
+		{Path: inner, Fill: c},
+	}
+}
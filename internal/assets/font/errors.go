@@ -0,0 +1,6 @@
+package font
+
+import "fmt"
+
+// ErrUnavailable is returned when a font is not available on this platform.
+var ErrUnavailable = fmt.Errorf("font not available on this platform")
@@ -1,5 +1,8 @@
 //go:build darwin
 
+// Package font embeds the fonts used by Apple for setup codes. Their licenses
+// only allow redistribution on Apple platforms, so they are not available
+// elsewhere and [ErrUnavailable] is returned instead.
 package font
 
 import (
@@ -0,0 +1,17 @@
+//go:build !darwin
+
+package font
+
+import "golang.org/x/image/font/opentype"
+
+// Scancardium returns [ErrUnavailable] as the font is only available on Apple
+// platforms.
+func Scancardium() (*opentype.Font, error) {
+	return nil, ErrUnavailable
+}
+
+// SFMonoBold returns [ErrUnavailable] as the font is only available on Apple
+// platforms.
+func SFMonoBold() (*opentype.Font, error) {
+	return nil, ErrUnavailable
+}
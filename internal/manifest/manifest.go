@@ -0,0 +1,219 @@
+// Package manifest implements production manifests. A manifest records, for
+// each created code file, the setup information, the setup payload and setup
+// hash derived from it and the SHA-256 hash of the file. It is written as CSV
+// and JSON and can be verified against the files later on.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+	"github.com/lukasmalkmus/hkcode/hk/qr"
+)
+
+// File names of the manifests written next to the code files.
+const (
+	CSVName  = "manifest.csv"
+	JSONName = "manifest.json"
+)
+
+// ErrMismatch is returned when an entry doesn't match its file or the setup
+// information it records.
+var ErrMismatch = fmt.Errorf("manifest mismatch")
+
+// Entry is the manifest entry of a code file. The setup information is stored
+// in its text form. The file path is relative to the manifest.
+type Entry struct {
+	Serial    string `json:"serial"`
+	Code      string `json:"code"`
+	ID        string `json:"id"`
+	Category  string `json:"category"`
+	Flags     string `json:"flags"`
+	DeviceID  string `json:"device_id,omitempty"`
+	Payload   string `json:"payload"`
+	SetupHash string `json:"setup_hash,omitempty"`
+	File      string `json:"file"`
+	SHA256    string `json:"sha256"`
+}
+
+// columns are the CSV columns in the order of the [Entry] fields.
+var columns = []string{
+	"serial", "code", "id", "category", "flags", "device_id", "payload",
+	"setup_hash", "file", "sha256",
+}
+
+// NewEntry returns the entry of the code file with the given contents created
+// for the setup information. The setup hash is only set if the setup
+// information has a device id.
+func NewEntry(info hk.SetupInfo, file string, data []byte) (Entry, error) {
+	payload, err := qr.CreatePayload(info)
+	if err != nil {
+		return Entry{}, err
+	}
+	category, err := info.Category.MarshalText()
+	if err != nil {
+		return Entry{}, err
+	}
+	flags, err := info.Flags.MarshalText()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{
+		Serial:   info.SerialNumber,
+		Code:     info.Code.String(),
+		ID:       info.ID.String(),
+		Category: string(category),
+		Flags:    string(flags),
+		Payload:  payload,
+		File:     filepath.ToSlash(file),
+		SHA256:   hashHex(data),
+	}
+	if info.DeviceID != nil {
+		e.DeviceID = info.DeviceID.String()
+		e.SetupHash = hk.SetupHash(info.ID, *info.DeviceID)
+	}
+	return e, nil
+}
+
+// SetupInfo parses the setup information recorded by the entry.
+func (e Entry) SetupInfo() (hk.SetupInfo, error) {
+	info := hk.SetupInfo{SerialNumber: e.Serial}
+
+	var err error
+	if info.Code, err = hk.ParseCode(e.Code); err != nil {
+		return hk.SetupInfo{}, err
+	}
+	if info.ID, err = hk.ParseID(e.ID); err != nil {
+		return hk.SetupInfo{}, err
+	}
+	if err = info.Category.UnmarshalText([]byte(e.Category)); err != nil {
+		return hk.SetupInfo{}, err
+	}
+	if err = info.Flags.UnmarshalText([]byte(e.Flags)); err != nil {
+		return hk.SetupInfo{}, err
+	}
+	if e.DeviceID != "" {
+		deviceID, err := hk.ParseDeviceID(e.DeviceID)
+		if err != nil {
+			return hk.SetupInfo{}, err
+		}
+		info.DeviceID = &deviceID
+	}
+	return info, nil
+}
+
+// Verify re-derives the payload and setup hash from the setup information of
+// the entry and re-hashes its file, relative to dir. All mismatches are
+// returned at once, joined by [errors.Join], and wrap [ErrMismatch].
+func (e Entry) Verify(dir string) error {
+	info, err := e.SetupInfo()
+	if err != nil {
+		return err
+	}
+	want, err := NewEntry(info, e.File, nil)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if e.Payload != want.Payload {
+		errs = append(errs, fmt.Errorf("%w: payload is %q, want %q", ErrMismatch, e.Payload, want.Payload))
+	}
+	if e.SetupHash != want.SetupHash {
+		errs = append(errs, fmt.Errorf("%w: setup hash is %q, want %q", ErrMismatch, e.SetupHash, want.SetupHash))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(e.File)))
+	if err != nil {
+		errs = append(errs, err)
+	} else if sum := hashHex(data); e.SHA256 != sum {
+		errs = append(errs, fmt.Errorf("%w: file %s has SHA-256 %s, want %s", ErrMismatch, e.File, sum, e.SHA256))
+	}
+	return errors.Join(errs...)
+}
+
+// WriteJSON writes the entries as an indented JSON array to w.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// ReadJSON reads entries written by [WriteJSON] from r.
+func ReadJSON(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WriteCSV writes the entries as CSV with a header row to w.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(columns)
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.Serial, e.Code, e.ID, e.Category, e.Flags, e.DeviceID, e.Payload,
+			e.SetupHash, e.File, e.SHA256,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads entries written by [WriteCSV] from r. The header row must
+// match.
+func ReadCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(columns)
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return nil, fmt.Errorf("missing header")
+	}
+	for i, name := range records[0] {
+		if name != columns[i] {
+			return nil, fmt.Errorf("unexpected column %q, want %q", name, columns[i])
+		}
+	}
+
+	entries := make([]Entry, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		entries = append(entries, Entry{
+			Serial:    rec[0],
+			Code:      rec[1],
+			ID:        rec[2],
+			Category:  rec[3],
+			Flags:     rec[4],
+			DeviceID:  rec[5],
+			Payload:   rec[6],
+			SetupHash: rec[7],
+			File:      rec[8],
+			SHA256:    rec[9],
+		})
+	}
+	return entries, nil
+}
+
+// hashHex returns the hex encoded SHA-256 hash of the data.
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+package manifest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func testEntry(t *testing.T, dir string) Entry {
+	t.Helper()
+
+	deviceID, err := hk.ParseDeviceID("AA:BB:CC:DD:EE:FF")
+	require.NoError(t, err)
+	info := hk.SetupInfo{
+		Code:         12344321,
+		ID:           "MHKA",
+		Flags:        hk.FlagIP,
+		Category:     hk.CategoryOutlet,
+		SerialNumber: "SN1",
+		DeviceID:     &deviceID,
+	}
+
+	data := []byte("code")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "codes"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "codes", "SN1.png"), data, 0o644))
+
+	e, err := NewEntry(info, filepath.Join("codes", "SN1.png"), data)
+	require.NoError(t, err)
+	return e
+}
+
+func TestNewEntry(t *testing.T) {
+	e := testEntry(t, t.TempDir())
+
+	assert.Equal(t, Entry{
+		Serial:    "SN1",
+		Code:      "12344321",
+		ID:        "MHKA",
+		Category:  "outlet",
+		Flags:     "ip",
+		DeviceID:  "AA:BB:CC:DD:EE:FF",
+		Payload:   e.Payload,
+		SetupHash: hk.SetupHash("MHKA", hk.DeviceID{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}),
+		File:      "codes/SN1.png",
+		SHA256:    "5694d08a2e53ffcae0c3103e5ad6f6076abd960eb1f8a56577040bc1028f702b",
+	}, e)
+	assert.Regexp(t, `^X-HM://[0-9A-Z]{9}MHKA$`, e.Payload)
+}
+
+func TestEntry_Verify(t *testing.T) {
+	dir := t.TempDir()
+	e := testEntry(t, dir)
+
+	require.NoError(t, e.Verify(dir))
+
+	tampered := e
+	tampered.Code = "12344322"
+	err := tampered.Verify(dir)
+	assert.ErrorIs(t, err, ErrMismatch)
+	assert.ErrorContains(t, err, "payload is")
+
+	tampered = e
+	tampered.SetupHash = "AAAAAA=="
+	assert.ErrorContains(t, tampered.Verify(dir), `setup hash is "AAAAAA=="`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "codes", "SN1.png"), []byte("cod"), 0o644))
+	err = e.Verify(dir)
+	assert.ErrorIs(t, err, ErrMismatch)
+	assert.ErrorContains(t, err, "file codes/SN1.png has SHA-256")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "codes", "SN1.png")))
+	assert.ErrorIs(t, e.Verify(dir), os.ErrNotExist)
+}
+
+func TestReadWrite(t *testing.T) {
+	e := testEntry(t, t.TempDir())
+	other := Entry{Serial: "SN2, \"quoted\"", Code: "00000001", ID: "ABCD", Category: "switch", Flags: "none", File: "SN2.svg"}
+	entries := []Entry{e, other}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, entries))
+	got, err := ReadCSV(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, entries, got)
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, entries))
+	got, err = ReadJSON(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, entries, got)
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+
+	_, err = ReadCSV(bytes.NewBufferString("serial,code\n"))
+	assert.Error(t, err)
+}
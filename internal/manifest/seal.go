@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// File extensions of encrypted manifests and of manifest signatures.
+const (
+	SealedExt    = ".box"
+	SignatureExt = ".sig"
+)
+
+// KeySize is the size of private and public keys in bytes.
+const KeySize = 32
+
+// ErrSignature is returned when a manifest signature is not valid.
+var ErrSignature = fmt.Errorf("invalid manifest signature")
+
+// Seal encrypts the manifest for the holder of the private key belonging to
+// the recipient public key. It uses an anonymous NaCl box, so only the
+// recipient can decrypt it.
+func Seal(data []byte, recipient *[KeySize]byte) ([]byte, error) {
+	return box.SealAnonymous(nil, data, recipient, rand.Reader)
+}
+
+// Open decrypts a manifest encrypted by [Seal] with the private key of the
+// recipient.
+func Open(sealed []byte, privateKey *[KeySize]byte) ([]byte, error) {
+	publicKey, err := BoxPublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := box.OpenAnonymous(nil, sealed, publicKey, privateKey)
+	if !ok {
+		return nil, fmt.Errorf("decryption failed: wrong key or corrupted manifest")
+	}
+	return data, nil
+}
+
+// BoxPublicKey returns the public key of an encryption private key.
+func BoxPublicKey(privateKey *[KeySize]byte) (*[KeySize]byte, error) {
+	pub, err := curve25519.X25519(privateKey[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return (*[KeySize]byte)(pub), nil
+}
+
+// Sign returns the Ed25519 signature of the manifest, base64 encoded with a
+// trailing newline. The private key is given as seed.
+func Sign(data []byte, seed *[KeySize]byte) []byte {
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed[:]), data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// Verify checks a signature created by [Sign] with the Ed25519 public key.
+// Errors wrap [ErrSignature].
+func Verify(data, sig []byte, publicKey *[KeySize]byte) error {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if !ed25519.Verify(publicKey[:], data, b) {
+		return fmt.Errorf("%w: manifest was altered or signed with another key", ErrSignature)
+	}
+	return nil
+}
+
+// SignPublicKey returns the Ed25519 public key of a signing private key given
+// as seed.
+func SignPublicKey(seed *[KeySize]byte) *[KeySize]byte {
+	pub := ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey)
+	return (*[KeySize]byte)(pub)
+}
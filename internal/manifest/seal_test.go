@@ -0,0 +1,59 @@
+package manifest
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func testKey(t *testing.T) *[KeySize]byte {
+	t.Helper()
+
+	var key [KeySize]byte
+	_, err := rand.Read(key[:])
+	require.NoError(t, err)
+	return &key
+}
+
+func TestSealOpen(t *testing.T) {
+	data := []byte(`[{"serial":"SN1","code":"12344321"}]`)
+
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	gotPub, err := BoxPublicKey(priv)
+	require.NoError(t, err)
+	assert.Equal(t, pub, gotPub)
+
+	sealed, err := Seal(data, pub)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "12344321")
+
+	got, err := Open(sealed, priv)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	_, err = Open(sealed, testKey(t))
+	assert.Error(t, err)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = Open(sealed, priv)
+	assert.Error(t, err)
+}
+
+func TestSignVerify(t *testing.T) {
+	data := []byte("serial,code\nSN1,12344321\n")
+	seed := testKey(t)
+	pub := SignPublicKey(seed)
+
+	sig := Sign(data, seed)
+	require.NoError(t, Verify(data, sig, pub))
+
+	altered := []byte("serial,code\nSN1,12344322\n")
+	assert.ErrorIs(t, Verify(altered, sig, pub), ErrSignature)
+	assert.ErrorIs(t, Verify(data, sig, SignPublicKey(testKey(t))), ErrSignature)
+	assert.ErrorIs(t, Verify(data, []byte("not base64!"), pub), ErrSignature)
+}
@@ -0,0 +1,229 @@
+// Package pdf implements a minimal PDF writer for vector graphics. It supports
+// multiple pages with filled and stroked paths, which is all that is needed to
+// print setup codes. Text is drawn as glyph outlines, so no fonts are embedded.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+// PointsPerMM is the number of PDF points (1/72 inch) per millimetre.
+const PointsPerMM = 72 / 25.4
+
+// Matrix is an affine transformation [a b c d e f] as used by PDF, mapping
+// (x, y) to (a*x + c*y + e, b*x + d*y + f).
+type Matrix [6]float64
+
+// Document is a PDF document. Create one with [New].
+type Document struct {
+	pages []*Page
+}
+
+// New creates an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// Page is a page of a document. Its coordinate system is in points with the
+// origin in the bottom left corner and the Y axis increasing upwards.
+type Page struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+// AddPage adds a page of the given size in points.
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{width: width, height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Draw draws the shapes transformed by m. Stroke widths are transformed as
+// well. Transparency is not supported: Fully transparent fills and strokes are
+// skipped, other colors are drawn opaque.
+func (p *Page) Draw(m Matrix, shapes ...vector.Shape) {
+	p.printf("q %s %s %s %s %s %s cm\n", fm(m[0]), fm(m[1]), fm(m[2]), fm(m[3]), fm(m[4]), fm(m[5]))
+	for _, shape := range shapes {
+		if transparent(shape.Fill) {
+			shape.Fill = nil
+		}
+		if transparent(shape.Stroke) {
+			shape.Stroke = nil
+		}
+		if shape.Fill == nil && shape.Stroke == nil {
+			continue
+		}
+		if shape.Fill != nil {
+			r, g, b := rgb(shape.Fill)
+			p.printf("%s %s %s rg\n", r, g, b)
+		}
+		if shape.Stroke != nil {
+			r, g, b := rgb(shape.Stroke)
+			p.printf("%s %s %s RG %s w\n", r, g, b, f(shape.StrokeWidth))
+		}
+
+		p.path(shape.Path)
+
+		switch {
+		case shape.Fill != nil && shape.Stroke != nil:
+			p.printf("B\n")
+		case shape.Fill != nil:
+			p.printf("f\n")
+		default:
+			p.printf("S\n")
+		}
+	}
+	p.printf("Q\n")
+}
+
+// Line strokes a line from (x1, y1) to (x2, y2) with the given width and color
+// in page coordinates.
+func (p *Page) Line(x1, y1, x2, y2, width float64, c color.Color) {
+	r, g, b := rgb(c)
+	p.printf("q %s %s %s RG %s w %s %s m %s %s l S Q\n", r, g, b, f(width), f(x1), f(y1), f(x2), f(y2))
+}
+
+func (p *Page) path(path vector.Path) {
+	var cur vector.Point
+	for _, seg := range path {
+		a := seg.Args
+		switch seg.Op {
+		case vector.OpMoveTo:
+			p.printf("%s %s m\n", f(a[0].X), f(a[0].Y))
+			cur = a[0]
+		case vector.OpLineTo:
+			p.printf("%s %s l\n", f(a[0].X), f(a[0].Y))
+			cur = a[0]
+		case vector.OpQuadTo:
+			// PDF only knows cubic Bézier curves. Elevate the degree of the
+			// quadratic one.
+			c1 := vector.Point{X: cur.X + 2.0/3*(a[0].X-cur.X), Y: cur.Y + 2.0/3*(a[0].Y-cur.Y)}
+			c2 := vector.Point{X: a[1].X + 2.0/3*(a[0].X-a[1].X), Y: a[1].Y + 2.0/3*(a[0].Y-a[1].Y)}
+			p.printf("%s %s %s %s %s %s c\n", f(c1.X), f(c1.Y), f(c2.X), f(c2.Y), f(a[1].X), f(a[1].Y))
+			cur = a[1]
+		case vector.OpCubeTo:
+			p.printf("%s %s %s %s %s %s c\n", f(a[0].X), f(a[0].Y), f(a[1].X), f(a[1].Y), f(a[2].X), f(a[2].Y))
+			cur = a[2]
+		case vector.OpClose:
+			p.printf("h\n")
+		}
+	}
+}
+
+func (p *Page) printf(format string, v ...any) {
+	fmt.Fprintf(&p.content, format, v...)
+}
+
+// WriteTo writes the document to w. Page contents are compressed.
+//
+// Implements [io.WriterTo].
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	// Object numbers: 1 is the catalog, 2 the page tree and every page is
+	// followed by its content stream.
+	offsets := make([]int64, 0, 2+2*len(d.pages))
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			fmt.Fprintf(cw, "stream\n%s\nendstream\n", stream)
+		}
+		fmt.Fprintf(cw, "endobj\n")
+	}
+
+	fmt.Fprintf(cw, "%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+
+	var kids bytes.Buffer
+	for i := range d.pages {
+		if i > 0 {
+			kids.WriteByte(' ')
+		}
+		fmt.Fprintf(&kids, "%d 0 R", 3+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(d.pages)), nil)
+
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << >> >>",
+			f(p.width), f(p.height), 4+2*i), nil)
+
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return cw.n, err
+		} else if err := zw.Close(); err != nil {
+			return cw.n, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", content.Len()), content.Bytes())
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// countingWriter counts the bytes written to determine object offsets and
+// keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+func transparent(c color.Color) bool {
+	if c == nil {
+		return false
+	}
+	_, _, _, a := c.RGBA()
+	return a == 0
+}
+
+func rgb(c color.Color) (string, string, string) {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return f(float64(nc.R) / 255), f(float64(nc.G) / 255), f(float64(nc.B) / 255)
+}
+
+func f(v float64) string {
+	return vector.FormatFloat(v)
+}
+
+// fm formats matrix values which need a higher precision than coordinates, as
+// they scale them.
+func fm(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 6, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/internal/vector"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New()
+	for i := 0; i < 2; i++ {
+		var path vector.Path
+		path.MoveTo(0, 0)
+		path.QuadTo(10, 20, 30, 0)
+		path.Close()
+
+		p := doc.AddPage(595.276, 841.89)
+		p.Draw(Matrix{1, 0, 0, -1, 0, 841.89}, vector.Shape{Path: path, Fill: color.Black})
+		p.Line(0, 0, 10, 10, 0.5, color.White)
+	}
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.EqualValues(t, buf.Len(), n)
+
+	b := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(b, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(b, []byte("%%EOF\n")))
+	assert.Contains(t, buf.String(), "/Type /Pages /Kids [3 0 R 5 0 R] /Count 2")
+	assert.Contains(t, buf.String(), "/MediaBox [0 0 595.276 841.89]")
+
+	// The cross-reference table must point at the objects.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+	require.NotNil(t, m)
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(b[xref:], []byte("xref\n0 7\n")))
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(b[xref:], -1)
+	require.Len(t, offsets, 6)
+	for i, offset := range offsets {
+		off, err := strconv.Atoi(string(offset[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(b[off:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+
+	// Content streams must be compressed and contain the drawing operators.
+	loc := regexp.MustCompile(`(?s)4 0 obj\n<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindSubmatchIndex(b)
+	require.NotNil(t, loc)
+	length, err := strconv.Atoi(string(b[loc[2]:loc[3]]))
+	require.NoError(t, err)
+	zr, err := zlib.NewReader(bytes.NewReader(b[loc[1] : loc[1]+length]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "q 1 0 0 -1 0 841.89 cm\n"+
+		"0 0 0 rg\n"+
+		"0 0 m\n"+
+		"6.667 13.333 16.667 13.333 30 0 c\n"+
+		"h\n"+
+		"f\n"+
+		"Q\n"+
+		"q 1 1 1 RG 0.5 w 0 0 m 10 10 l S Q\n", string(content))
+}
+
+func TestFm(t *testing.T) {
+	assert.Equal(t, "0", fm(-0.0000001))
+	assert.Equal(t, "2.834646", fm(PointsPerMM))
+	assert.Equal(t, "-1", fm(-1))
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package registry
+
+import "os"
+
+// lock does nothing, the registry is not locked on this platform. Concurrent
+// runs must be avoided.
+func lock(f *os.File, exclusive bool) error {
+	return nil
+}
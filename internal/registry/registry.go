@@ -0,0 +1,282 @@
+// Package registry implements a local registry of issued setup codes. It is an
+// append-only log of JSON records, one per line, which is locked while in use,
+// so concurrent runs can't issue the same setup code, setup id or serial
+// number twice.
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+// ErrDuplicate is returned when setup information is already registered.
+var ErrDuplicate = fmt.Errorf("already registered")
+
+// Errors for the individual fields, all wrapping [ErrDuplicate].
+var (
+	ErrDuplicateCode   = fmt.Errorf("setup code %w", ErrDuplicate)
+	ErrDuplicateID     = fmt.Errorf("setup id %w", ErrDuplicate)
+	ErrDuplicateSerial = fmt.Errorf("serial number %w", ErrDuplicate)
+)
+
+// Record is an entry of the registry.
+type Record struct {
+	Info     hk.SetupInfo `json:"info"`
+	Time     time.Time    `json:"time"`
+	Operator string       `json:"operator,omitempty"`
+}
+
+// Registry is an open registry. It holds an exclusive lock on the log until it
+// is closed. Create one with [Open].
+type Registry struct {
+	f       *os.File
+	w       io.Writer // writes to f, replaced by tests to fail writes
+	records []Record
+
+	used     index
+	reserved map[hk.Code]bool
+}
+
+// index holds the setup codes, setup ids and serial numbers in use. Setup ids
+// are stored in their canonical uppercase form, so they are unique regardless
+// of case.
+type index struct {
+	codes   map[hk.Code]bool
+	ids     map[string]bool
+	serials map[string]bool
+}
+
+func newIndex() index {
+	return index{
+		codes:   make(map[hk.Code]bool),
+		ids:     make(map[string]bool),
+		serials: make(map[string]bool),
+	}
+}
+
+// check returns the fields of the setup information which are in use, joined
+// by [errors.Join]. Empty setup ids and serial numbers are not checked.
+func (ix index) check(info hk.SetupInfo) error {
+	var errs []error
+	if ix.codes[info.Code] {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicateCode, info.Code.Format()))
+	}
+	if info.ID != "" && ix.ids[info.ID.String()] {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicateID, info.ID))
+	}
+	if info.SerialNumber != "" && ix.serials[info.SerialNumber] {
+		errs = append(errs, fmt.Errorf("%w: %q", ErrDuplicateSerial, info.SerialNumber))
+	}
+	return errors.Join(errs...)
+}
+
+// add marks the fields of the setup information as used.
+func (ix index) add(info hk.SetupInfo) {
+	ix.codes[info.Code] = true
+	if info.ID != "" {
+		ix.ids[info.ID.String()] = true
+	}
+	if info.SerialNumber != "" {
+		ix.serials[info.SerialNumber] = true
+	}
+}
+
+// Open opens the registry at path for issuing setup codes, creating it and its
+// directory if needed. It blocks until other processes closed it. The registry
+// holds setup codes in plain text, so only the owner may read it.
+func Open(path string) (*Registry, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f, true); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock registry: %w", err)
+	}
+
+	records, valid, err := read(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Drop an incomplete last record left by an interrupted write, so new
+	// records start on a line of their own.
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, err
+	} else if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := &Registry{
+		f:        f,
+		w:        f,
+		used:     newIndex(),
+		reserved: make(map[hk.Code]bool),
+	}
+	for _, rec := range records {
+		r.used.add(rec.Info)
+	}
+	r.records = records
+	return r, nil
+}
+
+// Load returns the records of the registry at path. A missing registry has
+// no records.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := lock(f, false); err != nil {
+		return nil, fmt.Errorf("lock registry: %w", err)
+	}
+	records, _, err := read(f)
+	return records, err
+}
+
+// Records returns the records of the registry in the order they were added.
+func (r *Registry) Records() []Record {
+	return r.records
+}
+
+// Check returns the setup code, setup id and serial number of the setup
+// information which are registered or reserved already, joined by
+// [errors.Join]. Empty setup ids and serial numbers are not checked. Setup ids
+// are compared case-insensitively.
+func (r *Registry) Check(info hk.SetupInfo) error {
+	return r.used.check(info)
+}
+
+// Reserve checks the setup information and reserves it until it is added, so
+// it isn't issued twice before being written. Reservations are released when
+// the registry is closed.
+func (r *Registry) Reserve(info hk.SetupInfo) error {
+	if err := r.Check(info); err != nil {
+		return err
+	}
+	r.used.add(info)
+	r.reserved[info.Code] = true
+	return nil
+}
+
+// Add appends the records to the registry and syncs it to disk. Records not
+// reserved before are checked first and none are added if any is registered
+// already or they conflict with each other. The records are only marked as
+// used once they are written, so a failed write doesn't block them. If the
+// write fails, the registry is truncated to its previous size, so no partial
+// record is left for the next one to be appended to.
+func (r *Registry) Add(records ...Record) error {
+	var (
+		buf     bytes.Buffer
+		pending = newIndex()
+	)
+	for _, rec := range records {
+		if err := pending.check(rec.Info); err != nil {
+			return err
+		} else if !r.reserved[rec.Info.Code] {
+			if err := r.used.check(rec.Info); err != nil {
+				return err
+			}
+		}
+		pending.add(rec.Info)
+
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	size, err := r.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := r.w.Write(buf.Bytes()); err != nil {
+		return r.rollback(size, err)
+	} else if err := r.f.Sync(); err != nil {
+		return r.rollback(size, err)
+	}
+	for _, rec := range records {
+		r.used.add(rec.Info)
+		delete(r.reserved, rec.Info.Code)
+	}
+	r.records = append(r.records, records...)
+	return nil
+}
+
+// rollback truncates the registry to size after a failed write and returns
+// err, joined with the error of the truncation, if any.
+func (r *Registry) rollback(size int64, err error) error {
+	if terr := r.f.Truncate(size); terr != nil {
+		return errors.Join(err, fmt.Errorf("truncate registry: %w", terr))
+	} else if _, serr := r.f.Seek(size, io.SeekStart); serr != nil {
+		return errors.Join(err, fmt.Errorf("truncate registry: %w", serr))
+	}
+	return err
+}
+
+// Close releases the lock and closes the registry.
+func (r *Registry) Close() error {
+	return r.f.Close()
+}
+
+// Match reports whether the record matches the query. The query matches
+// serial numbers and operators containing it and setup codes and setup ids
+// equal to it, all case-insensitively.
+func (rec Record) Match(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if code, err := hk.ParseCode(query); err == nil && code == rec.Info.Code {
+		return true
+	}
+	return strings.EqualFold(query, rec.Info.ID.String()) ||
+		strings.Contains(strings.ToLower(rec.Info.SerialNumber), query) ||
+		strings.Contains(strings.ToLower(rec.Operator), query)
+}
+
+// read reads the records of the log and returns them along with the size of
+// its complete lines. An incomplete last line is ignored.
+func read(r io.Reader) ([]Record, int64, error) {
+	var (
+		records []Record
+		valid   int64
+	)
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return records, valid, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		valid += int64(len(b))
+
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, 0, fmt.Errorf("registry line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+}
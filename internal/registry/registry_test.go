@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukasmalkmus/hkcode/hk"
+)
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hkcode", "registry.jsonl")
+
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	r, err := Open(path)
+	require.NoError(t, err)
+
+	// Only the owner may read the issued setup codes.
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	fi, err = os.Stat(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm())
+
+	first := Record{
+		Info:     hk.SetupInfo{Code: 12344321, ID: "MHKA", SerialNumber: "SN1"},
+		Time:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Operator: "alice",
+	}
+	require.NoError(t, r.Add(first))
+
+	// Every field is checked on its own.
+	err = r.Add(Record{Info: hk.SetupInfo{Code: 12344321, ID: "ABCD", SerialNumber: "SN2"}})
+	assert.ErrorIs(t, err, ErrDuplicateCode)
+	assert.EqualError(t, err, "setup code already registered: 123-44-321")
+	err = r.Check(hk.SetupInfo{Code: 11122333, ID: "MHKA", SerialNumber: "SN1"})
+	assert.ErrorIs(t, err, ErrDuplicateID)
+	assert.ErrorIs(t, err, ErrDuplicateSerial)
+	assert.NotErrorIs(t, err, ErrDuplicateCode)
+
+	// Setup ids are unique regardless of case, like in records read from
+	// JSON, which are not normalized.
+	var mixed hk.SetupInfo
+	require.NoError(t, mixed.UnmarshalJSON([]byte(`{"code":"55566777","id":"mhka"}`)))
+	assert.ErrorIs(t, r.Check(mixed), ErrDuplicateID)
+	assert.ErrorIs(t, r.Check(hk.SetupInfo{Code: 55566777, ID: "mHkA"}), ErrDuplicateID)
+
+	// Text codes have no setup id.
+	require.NoError(t, r.Check(hk.SetupInfo{Code: 11122333}))
+
+	// Reserved setup information can't be issued twice before it is added.
+	second := Record{Info: hk.SetupInfo{Code: 11122333, ID: "ABCD"}, Time: first.Time}
+	require.NoError(t, r.Reserve(second.Info))
+	assert.ErrorIs(t, r.Check(hk.SetupInfo{Code: 55566777, ID: "ABCD"}), ErrDuplicateID)
+	require.NoError(t, r.Add(second))
+	assert.Equal(t, []Record{first, second}, r.Records())
+	require.NoError(t, r.Close())
+
+	records, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, second}, records)
+}
+
+func TestRegistry_Add(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.jsonl")
+	r, err := Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// Records conflicting with each other are rejected as a whole.
+	first := Record{Info: hk.SetupInfo{Code: 12344321, ID: "MHKA"}}
+	err = r.Add(first, Record{Info: hk.SetupInfo{Code: 11122333, ID: "mhka"}})
+	assert.ErrorIs(t, err, ErrDuplicateID)
+	assert.Empty(t, r.Records())
+	require.NoError(t, r.Check(first.Info))
+
+	// Records which failed to be written are not marked as used.
+	require.NoError(t, r.f.Close())
+	assert.Error(t, r.Add(first))
+	assert.NoError(t, r.Check(first.Info))
+	assert.Empty(t, r.Records())
+}
+
+// failingWriter writes up to n bytes to w and fails after that.
+type failingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) <= fw.n {
+		fw.n -= len(p)
+		return fw.w.Write(p)
+	}
+	n, _ := fw.w.Write(p[:fw.n])
+	fw.n = 0
+	return n, errors.New("disk full")
+}
+
+func TestRegistry_Add_PartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.jsonl")
+	r, err := Open(path)
+	require.NoError(t, err)
+
+	tm := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := Record{Info: hk.SetupInfo{Code: 12344321, ID: "MHKA"}, Time: tm}
+	require.NoError(t, r.Add(first))
+
+	// A write failing in the middle of the second of two records leaves
+	// nothing of either behind.
+	r.w = &failingWriter{w: r.f, n: 80}
+	second := Record{Info: hk.SetupInfo{Code: 11122333, ID: "ABCD"}, Time: tm}
+	third := Record{Info: hk.SetupInfo{Code: 55566777, ID: "WXYZ"}, Time: tm}
+	assert.EqualError(t, r.Add(second, third), "disk full")
+	assert.Equal(t, []Record{first}, r.Records())
+
+	// Load would wait for the lock held by r.
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records, valid, err := read(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first}, records)
+	assert.EqualValues(t, len(b), valid)
+
+	// The next records start on a line of their own.
+	r.w = r.f
+	require.NoError(t, r.Add(second))
+	require.NoError(t, r.Close())
+	records, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, second}, records)
+}
+
+func TestOpen_IncompleteRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.jsonl")
+
+	r, err := Open(path)
+	require.NoError(t, err)
+	rec := Record{Info: hk.SetupInfo{Code: 12344321, ID: "MHKA"}, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, r.Add(rec))
+	require.NoError(t, r.Close())
+
+	// An interrupted write leaves part of a record behind.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"info":{"code":"111`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{rec}, records)
+
+	r, err = Open(path)
+	require.NoError(t, err)
+	other := Record{Info: hk.SetupInfo{Code: 11122333, ID: "ABCD"}, Time: rec.Time}
+	require.NoError(t, r.Add(other))
+	require.NoError(t, r.Close())
+
+	records, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{rec, other}, records)
+
+	require.NoError(t, os.WriteFile(path, []byte("{}\nnot json\n"), 0o644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "registry line 2")
+}
+
+func TestRecord_Match(t *testing.T) {
+	rec := Record{
+		Info:     hk.SetupInfo{Code: 12344321, ID: "MHKA", SerialNumber: "Lamp-0042"},
+		Operator: "alice",
+	}
+
+	for _, query := range []string{"123-44-321", "12344321", "mhka", "lamp-00", "0042", "ALICE"} {
+		assert.True(t, rec.Match(query), query)
+	}
+	for _, query := range []string{"1234", "MHK", "bob", "lamp-0043"} {
+		assert.False(t, rec.Match(query), query)
+	}
+}
@@ -0,0 +1,102 @@
+// Package resolution converts between physical lengths and pixels and stores
+// the resolution of raster images in the files they are encoded to, so they are
+// printed at their intended size.
+package resolution
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// MMPerInch is the number of millimetres in an inch.
+const MMPerInch = 25.4
+
+// Pixels returns the number of pixels the given length in millimetres spans at
+// the given resolution in dots per inch, rounded to the nearest pixel.
+func Pixels(mm, dpi float64) int {
+	return int(math.Round(mm / MMPerInch * dpi))
+}
+
+// MM returns the length in millimetres the given number of pixels spans at the
+// given resolution in dots per inch.
+func MM(pixels, dpi float64) float64 {
+	return pixels / dpi * MMPerInch
+}
+
+// signatureLen is the length of the PNG file signature.
+const signatureLen = 8
+
+// EncodePNG writes the image to w in PNG format. If dpi is positive, the
+// resolution is stored in a pHYs chunk. The standard library encoder doesn't
+// write one, so it is inserted after the IHDR chunk.
+func EncodePNG(w io.Writer, img image.Image, dpi float64) error {
+	if dpi <= 0 {
+		return png.Encode(w, img)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	// The signature is followed by the IHDR chunk, which must come first.
+	const ihdrLen = 4 + 4 + 13 + 4
+	b := buf.Bytes()
+	if len(b) < signatureLen+ihdrLen || string(b[signatureLen+4:signatureLen+8]) != "IHDR" {
+		return fmt.Errorf("unexpected PNG encoding")
+	}
+
+	if _, err := w.Write(b[:signatureLen+ihdrLen]); err != nil {
+		return err
+	}
+	if _, err := w.Write(physChunk(dpi)); err != nil {
+		return err
+	}
+	_, err := w.Write(b[signatureLen+ihdrLen:])
+	return err
+}
+
+// physChunk returns a pHYs chunk for the given resolution in dots per inch.
+// PNG stores it in pixels per metre.
+func physChunk(dpi float64) []byte {
+	ppm := uint32(math.Round(dpi / MMPerInch * 1000))
+
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], ppm)
+	binary.BigEndian.PutUint32(chunk[12:], ppm)
+	chunk[16] = 1 // The unit is the metre.
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+	return chunk
+}
+
+// PNGDPI returns the resolution in dots per inch stored in the pHYs chunk of
+// the PNG encoded image or false if there is none.
+func PNGDPI(b []byte) (float64, bool) {
+	if len(b) < signatureLen {
+		return 0, false
+	}
+	for b = b[signatureLen:]; len(b) >= 12; {
+		length := binary.BigEndian.Uint32(b)
+		if uint64(length)+12 > uint64(len(b)) {
+			break
+		}
+		typ, data := string(b[4:8]), b[8:8+length]
+		if typ == "pHYs" && length == 9 && data[8] == 1 {
+			ppm := binary.BigEndian.Uint32(data)
+			return float64(ppm) * MMPerInch / 1000, true
+		}
+		if typ == "IDAT" {
+			break
+		}
+		b = b[12+length:]
+	}
+	return 0, false
+}
@@ -0,0 +1,38 @@
+package resolution
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPixels(t *testing.T) {
+	assert.Equal(t, 354, Pixels(15, 600))
+	assert.Equal(t, 300, Pixels(25.4, 300))
+	assert.InDelta(t, 15, MM(354.33, 600), 1e-3)
+}
+
+func TestEncodePNG(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodePNG(&buf, img, 600))
+
+	dpi, ok := PNGDPI(buf.Bytes())
+	require.True(t, ok)
+	assert.InDelta(t, 600, dpi, 0.01)
+
+	// The chunk is valid, else the decoder would reject it.
+	dec, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), dec.Bounds())
+
+	buf.Reset()
+	require.NoError(t, EncodePNG(&buf, img, 0))
+	_, ok = PNGDPI(buf.Bytes())
+	assert.False(t, ok)
+}
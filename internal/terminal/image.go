@@ -0,0 +1,151 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// WriteImage writes the image to w using the graphics protocol, followed by a
+// newline. Transparent areas are shown on white.
+func WriteImage(w io.Writer, img image.Image, g Graphics) error {
+	switch g {
+	case GraphicsKitty:
+		return writeKitty(w, img)
+	case GraphicsSixel:
+		return writeSixel(w, img)
+	}
+	return fmt.Errorf("terminal doesn't support graphics")
+}
+
+// kittyChunkSize is the maximum size of the base64 encoded data sent in a
+// single escape sequence of the kitty graphics protocol.
+const kittyChunkSize = 4096
+
+// writeKitty writes the image PNG encoded using the kitty graphics protocol.
+func writeKitty(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, onWhite(img)); err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	bw := bufio.NewWriter(w)
+	for first := true; first || len(data) > 0; first = false {
+		chunk := data
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		data = data[len(chunk):]
+
+		// Only the first chunk has the control data. m=1 announces more
+		// chunks.
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(bw, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, chunk)
+		} else {
+			fmt.Fprintf(bw, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// writeSixel writes the image in the sixel format, reduced to the web-safe
+// color palette.
+func writeSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	pal := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), palette.WebSafe)
+	draw.Draw(pal, pal.Bounds(), onWhite(img), b.Min, draw.Src)
+
+	bw := bufio.NewWriter(w)
+
+	// Pixel aspect ratio 1:1, then the size and the color registers with RGB
+	// components in percent.
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", b.Dx(), b.Dy())
+	used := make([]bool, len(pal.Palette))
+	for _, i := range pal.Pix {
+		used[i] = true
+	}
+	for i, c := range pal.Palette {
+		if used[i] {
+			r, g, b, _ := c.RGBA()
+			fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+		}
+	}
+
+	// Each band of six rows is drawn once per color, returning to its start
+	// in between.
+	row := make([]byte, b.Dx())
+	for y := 0; y < b.Dy(); y += 6 {
+		first := true
+		for i := range pal.Palette {
+			if !used[i] || !sixelRow(pal, y, uint8(i), row) {
+				continue
+			}
+			if !first {
+				bw.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", i)
+			writeRuns(bw, row)
+		}
+		bw.WriteByte('-')
+	}
+	bw.WriteString("\x1b\\\n")
+	return bw.Flush()
+}
+
+// sixelRow sets the sixels of the band starting at row y for the color index
+// and reports whether any pixel has that color.
+func sixelRow(img *image.Paletted, y int, index uint8, row []byte) bool {
+	var found bool
+	for x := range row {
+		var bits byte
+		for dy := 0; dy < 6 && y+dy < img.Rect.Dy(); dy++ {
+			if img.Pix[img.PixOffset(x, y+dy)] == index {
+				bits |= 1 << dy
+			}
+		}
+		row[x] = '?' + bits
+		found = found || bits != 0
+	}
+	return found
+}
+
+// writeRuns writes the sixels, run-length encoding repetitions.
+func writeRuns(bw *bufio.Writer, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(bw, "!%d%c", n, row[x])
+		} else {
+			for i := 0; i < n; i++ {
+				bw.WriteByte(row[x])
+			}
+		}
+		x += n
+	}
+}
+
+// onWhite returns the image composed over a white background.
+func onWhite(img image.Image) image.Image {
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(res, res.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(res, res.Bounds(), img, b.Min, draw.Over)
+	return res
+}
@@ -0,0 +1,143 @@
+// Package terminal detects the graphics protocols supported by terminals and
+// writes images using them.
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Graphics is a protocol to display images in a terminal.
+type Graphics int
+
+// The supported graphics protocols.
+const (
+	// GraphicsNone means the terminal can only display text.
+	GraphicsNone Graphics = iota
+	// GraphicsSixel is the DEC sixel format supported by xterm, foot, mlterm
+	// and others.
+	GraphicsSixel
+	// GraphicsKitty is the graphics protocol of the kitty terminal, also
+	// supported by WezTerm and Ghostty.
+	GraphicsKitty
+)
+
+// queryTimeout is how long to wait for the terminal to answer a query.
+const queryTimeout = 200 * time.Millisecond
+
+// DetectGraphics returns the graphics protocol supported by the controlling
+// terminal. Terminals known to support the kitty protocol are identified by
+// the environment, as read by getenv. Others are asked for their primary device
+// attributes, which include sixel support. The terminal is assumed to support
+// neither if there is no controlling terminal or it doesn't answer in time.
+func DetectGraphics(getenv func(string) string) Graphics {
+	if g := detectEnv(getenv); g != GraphicsNone {
+		return g
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return GraphicsNone
+	}
+	defer tty.Close()
+
+	if querySixel(tty) {
+		return GraphicsSixel
+	}
+	return GraphicsNone
+}
+
+// detectEnv returns the graphics protocol supported by the terminal as
+// identified by the environment.
+func detectEnv(getenv func(string) string) Graphics {
+	termName, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case termName == "xterm-kitty", termName == "xterm-ghostty", getenv("KITTY_WINDOW_ID") != "",
+		program == "WezTerm", program == "ghostty":
+		return GraphicsKitty
+	case strings.Contains(termName, "sixel"), termName == "foot", strings.HasPrefix(termName, "foot-"),
+		termName == "mlterm", program == "iTerm.app":
+		return GraphicsSixel
+	}
+	return GraphicsNone
+}
+
+// querySixel asks the terminal for its primary device attributes and reports
+// whether they include sixel graphics.
+func querySixel(tty *os.File) bool {
+	// Don't use tty.Fd, it puts the file into blocking mode, which disables
+	// read deadlines.
+	rc, err := tty.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var fd int
+	if err := rc.Control(func(f uintptr) { fd = int(f) }); err != nil {
+		return false
+	}
+	if !term.IsTerminal(fd) {
+		return false
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return false
+	}
+	defer term.Restore(fd, state)
+
+	if _, err := tty.WriteString("\x1b[c"); err != nil {
+		return false
+	}
+	answer, err := readDeviceAttributes(tty, time.Now().Add(queryTimeout))
+	if err != nil {
+		return false
+	}
+	return parseDeviceAttributes(answer)
+}
+
+// readDeviceAttributes reads the answer to a device attributes query, which
+// has the form ESC [ ? 62 ; 4 ; ... c. Reading blocks until the terminal
+// answers, so it gives up at the deadline. If the file doesn't support
+// deadlines, it doesn't read at all.
+func readDeviceAttributes(f *os.File, deadline time.Time) ([]byte, error) {
+	if err := f.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer f.SetReadDeadline(time.Time{})
+
+	var (
+		buf []byte
+		b   = make([]byte, 64)
+	)
+	for bytes.IndexByte(buf, 'c') < 0 {
+		n, err := f.Read(b)
+		buf = append(buf, b[:n]...)
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// parseDeviceAttributes reports whether the answer to a primary device
+// attributes query includes sixel graphics, attribute 4.
+func parseDeviceAttributes(answer []byte) bool {
+	start := bytes.Index(answer, []byte("\x1b[?"))
+	if start < 0 {
+		return false
+	}
+	answer = answer[start+3:]
+	end := bytes.IndexByte(answer, 'c')
+	if end < 0 {
+		return false
+	}
+	for _, attr := range strings.Split(string(answer[:end]), ";") {
+		if attr == "4" {
+			return true
+		}
+	}
+	return false
+}
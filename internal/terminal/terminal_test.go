@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Graphics
+	}{
+		{map[string]string{"TERM": "xterm-256color"}, GraphicsNone},
+		{map[string]string{"TERM": "xterm-kitty"}, GraphicsKitty},
+		{map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, GraphicsKitty},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, GraphicsKitty},
+		{map[string]string{"TERM": "foot"}, GraphicsSixel},
+		{map[string]string{"TERM": "xterm-sixel"}, GraphicsSixel},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, GraphicsSixel},
+	}
+	for _, tt := range tests {
+		got := detectEnv(func(key string) string { return tt.env[key] })
+		assert.Equal(t, tt.want, got, "%v", tt.env)
+	}
+}
+
+func TestParseDeviceAttributes(t *testing.T) {
+	assert.True(t, parseDeviceAttributes([]byte("\x1b[?62;4;6;22c")))
+	assert.True(t, parseDeviceAttributes([]byte("junk\x1b[?64;1;2;4c")))
+	assert.False(t, parseDeviceAttributes([]byte("\x1b[?62;1;6;22c")))
+	assert.False(t, parseDeviceAttributes([]byte("\x1b[?1;2c")))
+	assert.False(t, parseDeviceAttributes([]byte("\x1b[?62;4")))
+	assert.False(t, parseDeviceAttributes(nil))
+}
+
+func TestReadDeviceAttributes(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	_, err = w.WriteString("\x1b[?62;4")
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.WriteString(";22c")
+	}()
+	answer, err := readDeviceAttributes(r, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b[?62;4;22c", string(answer))
+
+	// A terminal which doesn't answer must not block the read past the
+	// deadline.
+	start := time.Now()
+	_, err = readDeviceAttributes(r, start.Add(50*time.Millisecond))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWriteImage_Kitty(t *testing.T) {
+	// Noise doesn't compress, so the PNG needs several chunks.
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			img.Set(x, y, color.Gray{Y: uint8(rnd.Intn(256))})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteImage(&buf, img, GraphicsKitty))
+
+	// The PNG is split into several chunks, only the last one has m=0.
+	chunks := regexp.MustCompile("\x1b_G([^;]*);([^\x1b]*)\x1b\\\\").FindAllStringSubmatch(buf.String(), -1)
+	require.Greater(t, len(chunks), 1)
+	assert.Equal(t, "a=T,f=100,m=1", chunks[0][1])
+	assert.Equal(t, "m=0", chunks[len(chunks)-1][1])
+
+	var data strings.Builder
+	for _, c := range chunks {
+		assert.LessOrEqual(t, len(c[2]), kittyChunkSize)
+		data.WriteString(c[2])
+	}
+	b, err := base64.StdEncoding.DecodeString(data.String())
+	require.NoError(t, err)
+	dec, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), dec.Bounds())
+	// Transparent pixels are white.
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.RGBAModel.Convert(dec.At(299, 199)))
+}
+
+func TestWriteImage_Sixel(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteImage(&buf, testImage(20, 13), GraphicsSixel))
+
+	s := buf.String()
+	require.True(t, strings.HasPrefix(s, "\x1bP0;1;0q\"1;1;20;13"))
+	require.True(t, strings.HasSuffix(s, "\x1b\\\n"))
+	// Black and white are defined, each band of six rows ends with "-".
+	assert.Contains(t, s, "#0;2;0;0;0")
+	assert.Contains(t, s, "#215;2;100;100;100")
+	assert.Equal(t, 3, strings.Count(s, "-"))
+	// The top left 10x10 pixels are black: Two full bands and four rows.
+	assert.Contains(t, s, "#0!10~")
+	assert.Contains(t, s, "#0!10N")
+
+	assert.Error(t, WriteImage(&buf, testImage(1, 1), GraphicsNone))
+}
+
+// testImage returns a transparent image with a black square of half its size in
+// the top left corner.
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, image.Rect(0, 0, width/2, width/2), image.Black, image.Point{}, draw.Src)
+	return img
+}
@@ -2,7 +2,7 @@ package testdata
 
 import (
 	"bytes"
-	_ "embed"
+	"embed"
 	"image"
 	"image/png"
 	"testing"
@@ -11,21 +11,16 @@ import (
 )
 
 var (
-	//go:embed text_golden.png
-	textGolden []byte
 	//go:embed qr_golden.png
 	qrGolden []byte
-	//go:embed qr_boxed_golden.png
-	qrBoxedGolden []byte
+	//go:embed text_golden_*.png qr_boxed_golden_*.png
+	fontGoldens embed.FS
 )
 
 // GetGoldenTextImage returns the golden image for the text based Apple HomeKit®
-// setup code.
-func GetGoldenTextImage(t *testing.T) image.Image {
-	golden, err := png.Decode(bytes.NewReader(textGolden))
-	require.NoError(t, err)
-
-	return golden
+// setup code set in the built-in font with the given name.
+func GetGoldenTextImage(t *testing.T, font string) image.Image {
+	return getFontGolden(t, "text_golden_"+font+".png")
 }
 
 // GetGoldenQRCodeImage returns the golden image for the QR code based Apple
@@ -38,9 +33,17 @@ func GetGoldenQRCodeImage(t *testing.T) image.Image {
 }
 
 // GetGoldenBoxedQRCodeImage returns the golden image for the boxed QR code
-// based Apple HomeKit® setup code.
-func GetGoldenBoxedQRCodeImage(t *testing.T) image.Image {
-	golden, err := png.Decode(bytes.NewReader(qrBoxedGolden))
+// based Apple HomeKit® setup code with the digits set in the built-in font
+// with the given name.
+func GetGoldenBoxedQRCodeImage(t *testing.T, font string) image.Image {
+	return getFontGolden(t, "qr_boxed_golden_"+font+".png")
+}
+
+func getFontGolden(t *testing.T, name string) image.Image {
+	b, err := fontGoldens.ReadFile(name)
+	require.NoError(t, err)
+
+	golden, err := png.Decode(bytes.NewReader(b))
 	require.NoError(t, err)
 
 	return golden
@@ -0,0 +1,240 @@
+package vector
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xvector "golang.org/x/image/vector"
+)
+
+// miterLimit is the maximum ratio of the miter length to the stroke width
+// before a join is beveled, the SVG default.
+const miterLimit = 4
+
+// Draw rasterizes the shapes scaled by s onto dst in order. Edges are
+// anti-aliased. Strokes are drawn like SVG does by default: Lines are joined
+// with miters and have butt caps.
+func Draw(dst draw.Image, s float64, shapes ...Shape) {
+	for _, shape := range shapes {
+		path := shape.Path.Transform(s, 0, 0)
+		if shape.Fill != nil {
+			fill(dst, path, shape.Fill)
+		}
+		if shape.Stroke != nil {
+			fill(dst, strokeOutline(path, shape.StrokeWidth*s), shape.Stroke)
+		}
+	}
+}
+
+// fill fills the path using the non-zero winding rule.
+func fill(dst draw.Image, path Path, c color.Color) {
+	b := dst.Bounds()
+	z := xvector.NewRasterizer(b.Dx(), b.Dy())
+
+	pt := func(p Point) (float32, float32) {
+		return float32(p.X - float64(b.Min.X)), float32(p.Y - float64(b.Min.Y))
+	}
+	for _, seg := range path {
+		a := seg.Args
+		switch seg.Op {
+		case OpMoveTo:
+			z.MoveTo(pt(a[0]))
+		case OpLineTo:
+			z.LineTo(pt(a[0]))
+		case OpQuadTo:
+			bx, by := pt(a[0])
+			cx, cy := pt(a[1])
+			z.QuadTo(bx, by, cx, cy)
+		case OpCubeTo:
+			bx, by := pt(a[0])
+			cx, cy := pt(a[1])
+			dx, dy := pt(a[2])
+			z.CubeTo(bx, by, cx, cy, dx, dy)
+		case OpClose:
+			z.ClosePath()
+		}
+	}
+	z.Draw(dst, b, image.NewUniform(c), image.Point{})
+}
+
+// strokeOutline returns the outline of the stroke of the path with the given
+// width as a union of polygons: One for each flattened line segment and one
+// for each join. All polygons have the same orientation, so filling them with
+// the non-zero winding rule covers their union.
+func strokeOutline(path Path, width float64) Path {
+	var (
+		outline Path
+		h       = width / 2
+	)
+	polygon := func(pts ...Point) {
+		// Make all polygons run clockwise, so overlapping ones don't cancel
+		// each other out.
+		var area float64
+		for i, p := range pts {
+			q := pts[(i+1)%len(pts)]
+			area += p.X*q.Y - q.X*p.Y
+		}
+		if area < 0 {
+			for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+				pts[i], pts[j] = pts[j], pts[i]
+			}
+		}
+		outline.MoveTo(pts[0].X, pts[0].Y)
+		for _, p := range pts[1:] {
+			outline.LineTo(p.X, p.Y)
+		}
+		outline.Close()
+	}
+
+	for _, sub := range flatten(path) {
+		pts, closed := sub.points, sub.closed
+		if len(pts) < 2 {
+			continue
+		}
+
+		segments := len(pts) - 1
+		if closed {
+			segments++
+		}
+		for i := 0; i < segments; i++ {
+			p, q := pts[i], pts[(i+1)%len(pts)]
+			n := normal(p, q).scale(h)
+			polygon(p.add(n), q.add(n), q.sub(n), p.sub(n))
+		}
+
+		// Join at the inner points and, for closed sub-paths, where the end
+		// meets the start.
+		first, last := 1, len(pts)-1
+		if closed {
+			first, last = 0, len(pts)
+		}
+		for i := first; i < last; i++ {
+			prev := pts[(i-1+len(pts))%len(pts)]
+			p := pts[i]
+			next := pts[(i+1)%len(pts)]
+
+			n1, n2 := normal(prev, p), normal(p, next)
+			cross := n1.X*n2.Y - n1.Y*n2.X
+			if math.Abs(cross) < 1e-9 {
+				continue
+			}
+			// The outer side of the join is opposite to the turn.
+			side := -h
+			if cross < 0 {
+				side = h
+			}
+			o1, o2 := n1.scale(side), n2.scale(side)
+
+			m := o1.add(o2)
+			ml := math.Hypot(m.X, m.Y)
+			cosHalf := (m.X*o1.X + m.Y*o1.Y) / (ml * h)
+			if cosHalf > 0 && 1/cosHalf <= miterLimit {
+				tip := p.add(m.scale(h / cosHalf / ml))
+				polygon(p, p.add(o1), tip, p.add(o2))
+			} else {
+				polygon(p, p.add(o1), p.add(o2))
+			}
+		}
+	}
+	return outline
+}
+
+// polyline is a flattened sub-path.
+type polyline struct {
+	points []Point
+	closed bool
+}
+
+// flatten approximates the curves of the path by line segments at most about
+// a unit long and splits it into sub-paths. Consecutive duplicate points are
+// removed, as is the end point of closed sub-paths if it is their start point.
+func flatten(path Path) []polyline {
+	var (
+		subs []polyline
+		cur  *polyline
+	)
+	add := func(p Point) {
+		if cur == nil {
+			subs = append(subs, polyline{})
+			cur = &subs[len(subs)-1]
+		}
+		if n := len(cur.points); n > 0 && cur.points[n-1] == p {
+			return
+		}
+		cur.points = append(cur.points, p)
+	}
+
+	var last Point
+	for _, seg := range path {
+		a := seg.Args
+		// Segments after a close continue from the start of the closed
+		// sub-path.
+		if cur == nil && seg.Op != OpMoveTo && seg.Op != OpClose {
+			add(last)
+		}
+		switch seg.Op {
+		case OpMoveTo:
+			cur = nil
+			add(a[0])
+			last = a[0]
+		case OpLineTo:
+			add(a[0])
+			last = a[0]
+		case OpQuadTo:
+			n := steps(last, a[0], a[1])
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				add(Point{
+					X: u*u*last.X + 2*u*t*a[0].X + t*t*a[1].X,
+					Y: u*u*last.Y + 2*u*t*a[0].Y + t*t*a[1].Y,
+				})
+			}
+			last = a[1]
+		case OpCubeTo:
+			n := steps(last, a[0], a[1], a[2])
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				add(Point{
+					X: u*u*u*last.X + 3*u*u*t*a[0].X + 3*u*t*t*a[1].X + t*t*t*a[2].X,
+					Y: u*u*u*last.Y + 3*u*u*t*a[0].Y + 3*u*t*t*a[1].Y + t*t*t*a[2].Y,
+				})
+			}
+			last = a[2]
+		case OpClose:
+			if cur != nil {
+				if n := len(cur.points); n > 1 && cur.points[n-1] == cur.points[0] {
+					cur.points = cur.points[:n-1]
+				}
+				cur.closed = true
+				last = cur.points[0]
+				cur = nil
+			}
+		}
+	}
+	return subs
+}
+
+// steps returns the number of line segments to approximate the curve with the
+// given control polygon by.
+func steps(pts ...Point) int {
+	var length float64
+	for i := 1; i < len(pts); i++ {
+		length += math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	return int(math.Min(256, math.Max(1, math.Ceil(length))))
+}
+
+// normal returns the unit normal of the line from p to q.
+func normal(p, q Point) Point {
+	d := q.sub(p)
+	l := math.Hypot(d.X, d.Y)
+	return Point{X: -d.Y / l, Y: d.X / l}
+}
+
+func (p Point) add(q Point) Point     { return Point{X: p.X + q.X, Y: p.Y + q.Y} }
+func (p Point) sub(q Point) Point     { return Point{X: p.X - q.X, Y: p.Y - q.Y} }
+func (p Point) scale(s float64) Point { return Point{X: p.X * s, Y: p.Y * s} }
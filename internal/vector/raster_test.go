@@ -0,0 +1,65 @@
+package vector
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDraw(t *testing.T) {
+	// A square stroked 2 units wide around a filled square with a hole.
+	var frame, filled Path
+	frame.Rect(2, 2, 16, 16)
+	filled.Rect(6, 6, 8, 8)
+	filled.MoveTo(8, 8)
+	filled.LineTo(8, 12)
+	filled.LineTo(12, 12)
+	filled.LineTo(12, 8)
+	filled.Close()
+
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	Draw(img, 2,
+		Shape{Path: frame, Stroke: color.White, StrokeWidth: 2},
+		Shape{Path: filled, Fill: color.White},
+	)
+
+	tests := []struct {
+		name string
+		x, y int
+		want uint8
+	}{
+		{"outside", 1, 1, 0},
+		{"stroke outer edge", 2, 20, 255},
+		{"stroke inner edge", 5, 20, 255},
+		{"stroke miter corner", 2, 2, 255},
+		{"inside frame", 8, 20, 0},
+		{"fill", 13, 20, 255},
+		{"hole", 20, 20, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, img.GrayAt(tt.x, tt.y).Y)
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	var p Path
+	p.MoveTo(0, 0)
+	p.LineTo(1, 0)
+	p.LineTo(1, 0)
+	p.QuadTo(2, 0, 2, 1)
+	p.LineTo(0, 0)
+	p.Close()
+	p.LineTo(0, 5)
+
+	subs := flatten(p)
+	if assert.Len(t, subs, 2) {
+		assert.True(t, subs[0].closed)
+		assert.Equal(t, Point{0, 0}, subs[0].points[0])
+		assert.NotEqual(t, Point{0, 0}, subs[0].points[len(subs[0].points)-1])
+		assert.Equal(t, polyline{points: []Point{{0, 0}, {0, 5}}}, subs[1])
+	}
+}
@@ -0,0 +1,62 @@
+package vector
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// WriteSVG writes an SVG document of the given size in pixels with the shapes
+// drawn in order.
+func WriteSVG(w io.Writer, width, height float64, shapes ...Shape) error {
+	return writeSVG(w, width, height, FormatFloat(width), FormatFloat(height), shapes)
+}
+
+// WritePrintSVG writes an SVG document like [WriteSVG] whose width and height
+// are given in millimetres, mm per pixel, so it is printed at that size. The
+// shapes are still drawn in pixels.
+func WritePrintSVG(w io.Writer, width, height, mm float64, shapes ...Shape) error {
+	return writeSVG(w, width, height, FormatFloat(width*mm)+"mm", FormatFloat(height*mm)+"mm", shapes)
+}
+
+func writeSVG(w io.Writer, width, height float64, widthAttr, heightAttr string, shapes []Shape) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		widthAttr, heightAttr, FormatFloat(width), FormatFloat(height))
+	for _, shape := range shapes {
+		fill := "none"
+		if shape.Fill != nil {
+			fill = Hex(shape.Fill)
+		}
+		fmt.Fprintf(bw, `<path d="%s" fill="%s"`, shape.Path.SVG(), fill)
+		if a, ok := opacity(shape.Fill); ok {
+			fmt.Fprintf(bw, ` fill-opacity="%s"`, a)
+		}
+		if shape.Stroke != nil {
+			fmt.Fprintf(bw, ` stroke="%s" stroke-width="%s"`, Hex(shape.Stroke), FormatFloat(shape.StrokeWidth))
+			if a, ok := opacity(shape.Stroke); ok {
+				fmt.Fprintf(bw, ` stroke-opacity="%s"`, a)
+			}
+		}
+		fmt.Fprintln(bw, "/>")
+	}
+	fmt.Fprintln(bw, "</svg>")
+
+	return bw.Flush()
+}
+
+// opacity returns the alpha of the color as used by the SVG opacity attributes
+// or false if it is opaque.
+func opacity(c color.Color) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	_, _, _, a := c.RGBA()
+	if a == 0xffff {
+		return "", false
+	}
+	return FormatFloat(float64(a) / 0xffff), true
+}
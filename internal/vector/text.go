@@ -0,0 +1,115 @@
+package vector
+
+import (
+	"fmt"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Text returns the glyph outlines of s set in the font f with the given size in
+// pixels per em. The baseline starts at (x, y). Kerning is applied like
+// [font.Drawer] does.
+func Text(f *sfnt.Font, s string, size, x, y float64) (Path, error) {
+	var (
+		buf  sfnt.Buffer
+		ppem = fixed.Int26_6(size * 64)
+		path Path
+		dot  = x
+		prev = sfnt.GlyphIndex(0)
+	)
+	for i, r := range s {
+		idx, err := f.GlyphIndex(&buf, r)
+		if err != nil {
+			return nil, fmt.Errorf("glyph index of %q: %w", r, err)
+		}
+		if i > 0 {
+			kern, err := f.Kern(&buf, prev, idx, ppem, font.HintingNone)
+			if err != nil && err != sfnt.ErrNotFound {
+				return nil, fmt.Errorf("kerning of %q: %w", r, err)
+			}
+			dot += fromFixed(kern)
+		}
+
+		segments, err := f.LoadGlyph(&buf, idx, ppem, nil)
+		if err != nil {
+			return nil, fmt.Errorf("load glyph %q: %w", r, err)
+		}
+		for j, seg := range segments {
+			var args [3]Point
+			for k, pt := range seg.Args {
+				args[k] = Point{X: dot + fromFixed(pt.X), Y: y + fromFixed(pt.Y)}
+			}
+			switch seg.Op {
+			case sfnt.SegmentOpMoveTo:
+				if j > 0 {
+					path.Close()
+				}
+				path = append(path, Segment{Op: OpMoveTo, Args: args})
+			case sfnt.SegmentOpLineTo:
+				path = append(path, Segment{Op: OpLineTo, Args: args})
+			case sfnt.SegmentOpQuadTo:
+				path = append(path, Segment{Op: OpQuadTo, Args: args})
+			case sfnt.SegmentOpCubeTo:
+				path = append(path, Segment{Op: OpCubeTo, Args: args})
+			}
+		}
+		if len(segments) > 0 {
+			path.Close()
+		}
+
+		advance, err := f.GlyphAdvance(&buf, idx, ppem, font.HintingNone)
+		if err != nil {
+			return nil, fmt.Errorf("advance of %q: %w", r, err)
+		}
+		dot += fromFixed(advance)
+		prev = idx
+	}
+	return path, nil
+}
+
+// TextWidth returns the advance width of s set in the font f with the given
+// size in pixels per em, including kerning.
+func TextWidth(f *sfnt.Font, s string, size float64) (float64, error) {
+	var (
+		buf   sfnt.Buffer
+		ppem  = fixed.Int26_6(size * 64)
+		width float64
+		prev  = sfnt.GlyphIndex(0)
+	)
+	for i, r := range s {
+		idx, err := f.GlyphIndex(&buf, r)
+		if err != nil {
+			return 0, fmt.Errorf("glyph index of %q: %w", r, err)
+		}
+		if i > 0 {
+			kern, err := f.Kern(&buf, prev, idx, ppem, font.HintingNone)
+			if err != nil && err != sfnt.ErrNotFound {
+				return 0, fmt.Errorf("kerning of %q: %w", r, err)
+			}
+			width += fromFixed(kern)
+		}
+		advance, err := f.GlyphAdvance(&buf, idx, ppem, font.HintingNone)
+		if err != nil {
+			return 0, fmt.Errorf("advance of %q: %w", r, err)
+		}
+		width += fromFixed(advance)
+		prev = idx
+	}
+	return width, nil
+}
+
+// Ascent returns the ascent of the font f with the given size in pixels per
+// em.
+func Ascent(f *sfnt.Font, size float64) (float64, error) {
+	m, err := f.Metrics(nil, fixed.Int26_6(size*64), font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	return fromFixed(m.Ascent), nil
+}
+
+func fromFixed(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
@@ -0,0 +1,179 @@
+// Package vector provides resolution independent paths and shapes shared by
+// the vector based renderers.
+package vector
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Op is the operation of a path segment.
+type Op uint8
+
+// All available operations.
+const (
+	OpMoveTo Op = iota
+	OpLineTo
+	OpQuadTo
+	OpCubeTo
+	OpClose
+)
+
+// Point is a point in a coordinate system whose Y axis increases downwards.
+type Point struct {
+	X, Y float64
+}
+
+// Segment is a segment of a path. Depending on its operation, it has up to
+// three points: The end point of a line, the control point and end point of a
+// quadratic Bézier curve or the two control points and the end point of a
+// cubic Bézier curve.
+type Segment struct {
+	Op   Op
+	Args [3]Point
+}
+
+// Path is a sequence of segments.
+type Path []Segment
+
+// MoveTo starts a new sub-path at (x, y).
+func (p *Path) MoveTo(x, y float64) {
+	*p = append(*p, Segment{Op: OpMoveTo, Args: [3]Point{{x, y}}})
+}
+
+// LineTo adds a line to (x, y).
+func (p *Path) LineTo(x, y float64) {
+	*p = append(*p, Segment{Op: OpLineTo, Args: [3]Point{{x, y}}})
+}
+
+// QuadTo adds a quadratic Bézier curve with the control point (cx, cy) to
+// (x, y).
+func (p *Path) QuadTo(cx, cy, x, y float64) {
+	*p = append(*p, Segment{Op: OpQuadTo, Args: [3]Point{{cx, cy}, {x, y}}})
+}
+
+// CubeTo adds a cubic Bézier curve with the control points (c1x, c1y) and
+// (c2x, c2y) to (x, y).
+func (p *Path) CubeTo(c1x, c1y, c2x, c2y, x, y float64) {
+	*p = append(*p, Segment{Op: OpCubeTo, Args: [3]Point{{c1x, c1y}, {c2x, c2y}, {x, y}}})
+}
+
+// Close closes the current sub-path.
+func (p *Path) Close() {
+	*p = append(*p, Segment{Op: OpClose})
+}
+
+// Rect adds the closed rectangle with the top left corner (x, y) and the size
+// w x h.
+func (p *Path) Rect(x, y, w, h float64) {
+	p.MoveTo(x, y)
+	p.LineTo(x+w, y)
+	p.LineTo(x+w, y+h)
+	p.LineTo(x, y+h)
+	p.Close()
+}
+
+// RoundedRect adds the closed rectangle with the top left corner (x, y), the
+// size w x h and corners rounded with the radius r.
+func (p *Path) RoundedRect(x, y, w, h, r float64) {
+	// Distance of the control points from the end points which approximates a
+	// quarter circle with a cubic Bézier curve.
+	k := r * 0.5522847498
+
+	p.MoveTo(x+r, y)
+	p.LineTo(x+w-r, y)
+	p.CubeTo(x+w-r+k, y, x+w, y+r-k, x+w, y+r)
+	p.LineTo(x+w, y+h-r)
+	p.CubeTo(x+w, y+h-r+k, x+w-r+k, y+h, x+w-r, y+h)
+	p.LineTo(x+r, y+h)
+	p.CubeTo(x+r-k, y+h, x, y+h-r+k, x, y+h-r)
+	p.LineTo(x, y+r)
+	p.CubeTo(x, y+r-k, x+r-k, y, x+r, y)
+	p.Close()
+}
+
+// Append adds all segments of q.
+func (p *Path) Append(q Path) {
+	*p = append(*p, q...)
+}
+
+// Transform returns a copy of the path with all points scaled by s and then
+// translated by (dx, dy).
+func (p Path) Transform(s, dx, dy float64) Path {
+	res := make(Path, len(p))
+	for i, seg := range p {
+		res[i].Op = seg.Op
+		for j, pt := range seg.Args {
+			res[i].Args[j] = Point{X: pt.X*s + dx, Y: pt.Y*s + dy}
+		}
+	}
+	return res
+}
+
+// SVG returns the path data of the path as used by the "d" attribute of SVG
+// path elements.
+func (p Path) SVG() string {
+	var sb strings.Builder
+	for _, seg := range p {
+		switch seg.Op {
+		case OpMoveTo:
+			sb.WriteByte('M')
+			writePoints(&sb, seg.Args[:1])
+		case OpLineTo:
+			sb.WriteByte('L')
+			writePoints(&sb, seg.Args[:1])
+		case OpQuadTo:
+			sb.WriteByte('Q')
+			writePoints(&sb, seg.Args[:2])
+		case OpCubeTo:
+			sb.WriteByte('C')
+			writePoints(&sb, seg.Args[:3])
+		case OpClose:
+			sb.WriteByte('Z')
+		}
+	}
+	return sb.String()
+}
+
+func writePoints(sb *strings.Builder, pts []Point) {
+	for i, pt := range pts {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(FormatFloat(pt.X))
+		sb.WriteByte(' ')
+		sb.WriteString(FormatFloat(pt.Y))
+	}
+}
+
+// FormatFloat formats f with at most three decimals and without trailing
+// zeros.
+func FormatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Shape is a path which is filled, stroked or both.
+type Shape struct {
+	Path Path
+	// Fill color of the path. Not filled if nil.
+	Fill color.Color
+	// Stroke color of the path. Not stroked if nil.
+	Stroke color.Color
+	// StrokeWidth is the width of the stroke. Lines are joined with miters and
+	// have butt caps.
+	StrokeWidth float64
+}
+
+// Hex returns the color c in the hexadecimal form #rrggbb, ignoring alpha.
+func Hex(c color.Color) string {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", nc.R, nc.G, nc.B)
+}
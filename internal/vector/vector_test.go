@@ -0,0 +1,43 @@
+package vector
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath_SVG(t *testing.T) {
+	var p Path
+	p.MoveTo(0, 0)
+	p.LineTo(1.5, 0)
+	p.QuadTo(2, 0, 2, 0.5)
+	p.CubeTo(2, 1, 1.25, 2, 1/3.0, 2)
+	p.Close()
+	assert.Equal(t, "M0 0L1.5 0Q2 0 2 0.5C2 1 1.25 2 0.333 2Z", p.SVG())
+
+	assert.Equal(t, "M2 3L4 3Z", Path{
+		{Op: OpMoveTo, Args: [3]Point{{0, 1}}},
+		{Op: OpLineTo, Args: [3]Point{{1, 1}}},
+		{Op: OpClose},
+	}.Transform(2, 2, 1).SVG())
+}
+
+func TestPath_Rect(t *testing.T) {
+	var p Path
+	p.Rect(1, 2, 3, 4)
+	assert.Equal(t, "M1 2L4 2L4 6L1 6Z", p.SVG())
+}
+
+func TestFormatFloat(t *testing.T) {
+	assert.Equal(t, "0", FormatFloat(0))
+	assert.Equal(t, "0", FormatFloat(-0.0001))
+	assert.Equal(t, "12", FormatFloat(12))
+	assert.Equal(t, "1.25", FormatFloat(1.25))
+	assert.Equal(t, "-8.828", FormatFloat(-8.8275))
+}
+
+func TestHex(t *testing.T) {
+	assert.Equal(t, "#000000", Hex(color.Black))
+	assert.Equal(t, "#11110e", Hex(color.RGBA{R: 0x11, G: 0x11, B: 0x0e, A: 0xff}))
+}